@@ -0,0 +1,472 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// DefaultAlertTemplate is the default template of alert messages. It
+// uses Slack/Mattermost markdown.
+const DefaultAlertTemplate = `*{{.Level | upper}}* on {{.Hostname}}: {{.Program}}` +
+	`{{if .HasExitCode}} exited with code {{.ExitCode}}{{end}}
+{{.Message}}{{with .Fields}} {{fields .}}{{end}}
+{{- if .Suppressed}}
+_{{.Suppressed}} similar alerts were suppressed._{{end}}
+{{- if .Lines}}
+` + "```" + `
+{{range .Lines}}{{.}}
+{{end}}` + "```" + `{{end}}`
+
+const (
+	// DefaultAlertLines is the default number of recent log lines
+	// included in an alert.
+	DefaultAlertLines = 10
+
+	// DefaultAlertRateLimit is the default largest number of
+	// alerts sent per DefaultAlertRatePeriod.
+	DefaultAlertRateLimit = 5
+
+	// DefaultAlertRatePeriod is the default period the rate limit
+	// applies to.
+	DefaultAlertRatePeriod = time.Hour
+
+	// DefaultAlertDedupWindow is the default time during which
+	// alerts with the same level and message are suppressed.
+	DefaultAlertDedupWindow = 10 * time.Minute
+
+	// DefaultAlertMaxRetries is the default number of times a
+	// failed alert is retried. It is low as alerts are sent
+	// synchronously.
+	DefaultAlertMaxRetries = 2
+)
+
+// AlertData is the data passed to the alert template. The template
+// may use the helper functions of TemplateFormatter, e.g., upper and
+// fields.
+type AlertData struct {
+	// Level the entry was logged at.
+	Level Level
+
+	// Message logged with the trailing newline, if any, removed.
+	Message string
+
+	// Fields attached to the entry.
+	Fields Fields
+
+	// Timestamp is the time the entry was created.
+	Timestamp time.Time
+
+	// Hostname and Program identify where the entry was logged.
+	Hostname string
+	Program  string
+
+	// HasExitCode is true if the program exits after the entry,
+	// e.g., when it was logged by FatalIfError. ExitCode is then
+	// the exit code.
+	HasExitCode bool
+	ExitCode    int
+
+	// Lines are the most recent log lines, ending with the entry.
+	Lines []string
+
+	// Suppressed is the number of alerts suppressed by rate
+	// limiting or deduplication since the last alert was sent.
+	Suppressed int
+}
+
+// AlertOptions configure an AlertHook.
+type AlertOptions struct {
+	// WebhookURL is the Slack or Mattermost incoming webhook URL.
+	WebhookURL string
+
+	// Levels are the levels that send an alert. The default is
+	// ErrorLevel, FatalLevel, and PanicLevel.
+	Levels []Level
+
+	// Template is the text/template rendering the alert message
+	// from AlertData. The default is DefaultAlertTemplate.
+	Template string
+
+	// Lines is the number of recent log lines included in an
+	// alert. Zero disables it. The default is DefaultAlertLines.
+	Lines int
+
+	// LineFormatter renders the recent log lines. The default is
+	// a LogfmtFormatter.
+	LineFormatter Formatter
+
+	// Hostname and Program identify the sender. The defaults are
+	// the host name and the base name of the executable.
+	Hostname string
+	Program  string
+
+	// Username, Channel, and IconEmoji optionally override the
+	// webhook defaults.
+	Username  string
+	Channel   string
+	IconEmoji string
+
+	// RateLimit is the largest number of alerts sent per
+	// RatePeriod. Zero disables rate limiting. The defaults are
+	// DefaultAlertRateLimit and DefaultAlertRatePeriod.
+	RateLimit  int
+	RatePeriod time.Duration
+
+	// DedupWindow is the time during which an alert with the same
+	// level and message as one already sent is suppressed. Zero
+	// disables deduplication. The default is
+	// DefaultAlertDedupWindow.
+	DedupWindow time.Duration
+
+	// StateFile, if set, stores the alerts sent so rate limiting
+	// and deduplication also apply across restarts, e.g., when a
+	// job is in a crash loop.
+	StateFile string
+
+	// MaxRetries, MinBackoff, and MaxBackoff control retrying
+	// failed alerts. The defaults are DefaultAlertMaxRetries,
+	// DefaultMinBackoff, and DefaultMaxBackoff.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// SendTimeout is the longest time sending an alert, including
+	// retries, may take. The default, zero, uses the flush timeout
+	// set with SetFlushTimeout so an alert for a Fatal*() message
+	// does not delay exiting any longer than flushing the loggers.
+	SendTimeout time.Duration
+
+	// Client is the HTTP client used for alerts. The default has
+	// a 5 second timeout.
+	Client *http.Client
+
+	// ErrorHandler is called when an alert cannot be sent. The
+	// default writes the error to stderr.
+	ErrorHandler func(err error)
+}
+
+// NewAlertOptions is the constructor for AlertOptions.
+func NewAlertOptions(webhookURL string) *AlertOptions {
+	return &AlertOptions{
+		WebhookURL:  webhookURL,
+		Levels:      []Level{PanicLevel, FatalLevel, ErrorLevel},
+		Template:    DefaultAlertTemplate,
+		Lines:       DefaultAlertLines,
+		RateLimit:   DefaultAlertRateLimit,
+		RatePeriod:  DefaultAlertRatePeriod,
+		DedupWindow: DefaultAlertDedupWindow,
+		MaxRetries:  DefaultAlertMaxRetries,
+		MinBackoff:  DefaultMinBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		Client:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// AlertHook is a Hook that posts an alert to a Slack or
+// Mattermost-compatible incoming webhook when an entry is logged at
+// one of its levels. Add it to a logger with AddHook:
+//
+//	options := conlog.NewAlertOptions(webhookURL)
+//	options.StateFile = "/var/tmp/nightly-job.alerts"
+//	hook, err := conlog.NewAlertHook(options)
+//	log.AddHook(hook)
+//
+// Alerts are sent synchronously so an alert for a Fatal*() message is
+// delivered before HandleExit exits. Other goroutines keep logging
+// while an alert is sent, and sending gives up after the
+// SendTimeout. Rate limiting and deduplication
+// keep a crash loop from flooding the channel.
+type AlertHook struct {
+	options AlertOptions
+	tmpl    *template.Template
+	alertOn map[Level]bool
+	retrier retrier
+
+	mu         sync.Mutex
+	lines      []string
+	next       int
+	sent       []alertRecord
+	suppressed int
+
+	suppressedTotal uint64
+}
+
+var _ Hook = (*AlertHook)(nil)
+
+// alertRecord is an alert that was sent.
+type alertRecord struct {
+	Key  string    `json:"key"`
+	Time time.Time `json:"time"`
+}
+
+// NewAlertHook is the constructor for AlertHook. A nil options is an
+// error as the webhook URL is required.
+func NewAlertHook(options *AlertOptions) (*AlertHook, error) {
+	if options == nil || options.WebhookURL == "" {
+		return nil, fmt.Errorf("alert webhook URL is required")
+	}
+	h := &AlertHook{
+		options: *options,
+		alertOn: make(map[Level]bool),
+	}
+	if h.options.Levels == nil {
+		h.options.Levels = NewAlertOptions("").Levels
+	}
+	for _, level := range h.options.Levels {
+		h.alertOn[level] = true
+	}
+	if h.options.Template == "" {
+		h.options.Template = DefaultAlertTemplate
+	}
+	tmpl, err := template.New("alert").Funcs((&TemplateFormatter{}).funcMap()).Parse(h.options.Template)
+	if err != nil {
+		return nil, err
+	}
+	h.tmpl = tmpl
+	if h.options.LineFormatter == nil {
+		h.options.LineFormatter = NewLogfmtFormatter()
+	}
+	if h.options.Hostname == "" {
+		h.options.Hostname, _ = os.Hostname()
+	}
+	if h.options.Program == "" {
+		h.options.Program = filepath.Base(os.Args[0])
+	}
+	if h.options.Client == nil {
+		h.options.Client = http.DefaultClient
+	}
+	if h.options.Lines > 0 {
+		h.lines = make([]string, h.options.Lines)
+	}
+	h.retrier = newRetrier(h.options.MaxRetries, h.options.MinBackoff, h.options.MaxBackoff)
+
+	return h, nil
+}
+
+// Levels returns the levels the hook is fired for. These are all
+// levels if recent lines are included in alerts, otherwise the alert
+// levels.
+func (h *AlertHook) Levels() []Level {
+	if h.options.Lines > 0 {
+		return GetAllLevels()
+	}
+
+	return h.options.Levels
+}
+
+// Fire records the entry as a recent line and sends an alert if it is
+// at one of the alert levels. Errors sending the alert are passed to
+// the ErrorHandler so they never cause the entry to be dropped.
+func (h *AlertHook) Fire(entry *Entry) error {
+	if h.options.Lines > 0 {
+		e := *entry
+		e.Buffer = nil
+		if line, err := h.options.LineFormatter.Format(&e); err == nil && len(line) > 0 {
+			h.mu.Lock()
+			h.lines[h.next%len(h.lines)] = strings.TrimSuffix(string(line), "\n")
+			h.next++
+			h.mu.Unlock()
+		}
+	}
+	if !h.alertOn[entry.Level] {
+		return nil
+	}
+
+	data := &AlertData{
+		Level:     entry.Level,
+		Message:   strings.TrimSuffix(entry.Message, "\n"),
+		Fields:    entry.Fields,
+		Timestamp: entry.Time,
+		Hostname:  h.options.Hostname,
+		Program:   h.options.Program,
+	}
+	if entry.HasExitCode() {
+		data.HasExitCode = true
+		data.ExitCode = *entry.ExitCode
+	}
+	key := alertKey(data)
+
+	// The lock is not held while the alert is sent so a slow
+	// webhook does not block other goroutines logging.
+	h.mu.Lock()
+	if !h.allow(key, entry.Time) {
+		h.suppressed++
+		h.mu.Unlock()
+		atomic.AddUint64(&h.suppressedTotal, 1)
+		return nil
+	}
+	data.Suppressed = h.suppressed
+	data.Lines = h.recentLines()
+	h.suppressed = 0
+	h.mu.Unlock()
+
+	if err := h.send(data); err != nil {
+		h.mu.Lock()
+		h.suppressed += data.Suppressed
+		h.mu.Unlock()
+		handleError(h.options.ErrorHandler, fmt.Errorf("failed to send alert, %v", err))
+	}
+
+	return nil
+}
+
+// Suppressed returns the number of alerts suppressed by rate limiting
+// or deduplication.
+func (h *AlertHook) Suppressed() uint64 {
+	return atomic.LoadUint64(&h.suppressedTotal)
+}
+
+// allow reports whether an alert with key may be sent at now and, if
+// so, records it. It is called with mu held.
+func (h *AlertHook) allow(key string, now time.Time) bool {
+	if h.options.StateFile != "" {
+		h.sent = h.loadState()
+	}
+	keep := h.options.RatePeriod
+	if h.options.DedupWindow > keep {
+		keep = h.options.DedupWindow
+	}
+	sent := h.sent[:0]
+	recent := 0
+	duplicate := false
+	for _, r := range h.sent {
+		age := now.Sub(r.Time)
+		if age >= keep {
+			continue
+		}
+		sent = append(sent, r)
+		if h.options.RateLimit > 0 && age < h.options.RatePeriod {
+			recent++
+		}
+		if h.options.DedupWindow > 0 && age < h.options.DedupWindow && r.Key == key {
+			duplicate = true
+		}
+	}
+	h.sent = sent
+	if duplicate || (h.options.RateLimit > 0 && recent >= h.options.RateLimit) {
+		return false
+	}
+
+	h.sent = append(h.sent, alertRecord{Key: key, Time: now})
+	if h.options.StateFile != "" {
+		h.saveState()
+	}
+
+	return true
+}
+
+func (h *AlertHook) loadState() []alertRecord {
+	var sent []alertRecord
+	b, err := ioutil.ReadFile(h.options.StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			handleError(h.options.ErrorHandler, fmt.Errorf("failed to read alert state file, %v", err))
+		}
+		return sent
+	}
+	if err := json.Unmarshal(b, &sent); err != nil {
+		handleError(h.options.ErrorHandler, fmt.Errorf("failed to read alert state file, %v", err))
+	}
+
+	return sent
+}
+
+// saveState writes the state file, replacing it atomically.
+func (h *AlertHook) saveState() {
+	b, err := json.Marshal(h.sent)
+	if err == nil {
+		tmp := h.options.StateFile + ".tmp"
+		err = ioutil.WriteFile(tmp, b, 0600)
+		if err == nil {
+			err = os.Rename(tmp, h.options.StateFile)
+		}
+	}
+	if err != nil {
+		handleError(h.options.ErrorHandler, fmt.Errorf("failed to write alert state file, %v", err))
+	}
+}
+
+// recentLines returns the recorded lines, oldest first. It is called
+// with mu held.
+func (h *AlertHook) recentLines() []string {
+	if len(h.lines) == 0 {
+		return nil
+	}
+	n := h.next
+	if n > len(h.lines) {
+		n = len(h.lines)
+	}
+	lines := make([]string, 0, n)
+	for i := h.next - n; i < h.next; i++ {
+		lines = append(lines, h.lines[i%len(h.lines)])
+	}
+
+	return lines
+}
+
+// send renders the alert and posts it to the webhook within the send
+// timeout.
+func (h *AlertHook) send(data *AlertData) error {
+	var text bytes.Buffer
+	if err := h.tmpl.Execute(&text, data); err != nil {
+		return err
+	}
+	payload := struct {
+		Text      string `json:"text"`
+		Username  string `json:"username,omitempty"`
+		Channel   string `json:"channel,omitempty"`
+		IconEmoji string `json:"icon_emoji,omitempty"`
+	}{
+		Text:      text.String(),
+		Username:  h.options.Username,
+		Channel:   h.options.Channel,
+		IconEmoji: h.options.IconEmoji,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+
+	ctx := context.Background()
+	timeout := h.options.SendTimeout
+	if timeout == 0 {
+		timeout = GetFlushTimeout()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return h.retrier.doContext(ctx, func(ctx context.Context) error {
+		return postBatchContext(ctx, h.options.Client, h.options.WebhookURL, header, body)
+	})
+}
+
+// alertKey identifies alerts that are duplicates of each other.
+func alertKey(data *AlertData) string {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(data.Level.String()))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(data.Message))
+
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
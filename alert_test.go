@@ -0,0 +1,234 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// alertWebhook records the text of the alerts posted to it.
+type alertWebhook struct {
+	*httptest.Server
+	mu       sync.Mutex
+	alerts   []string
+	channels []string
+}
+
+func newAlertWebhook(t *testing.T) *alertWebhook {
+	w := &alertWebhook{}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text    string `json:"text"`
+			Channel string `json:"channel"`
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("bad alert: %v", err)
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.mu.Lock()
+		w.alerts = append(w.alerts, payload.Text)
+		w.channels = append(w.channels, payload.Channel)
+		w.mu.Unlock()
+	}))
+
+	return w
+}
+
+func (w *alertWebhook) texts() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.alerts...)
+}
+
+func newTestAlertOptions(url string) *conlog.AlertOptions {
+	formatter := conlog.NewLogfmtFormatter()
+	formatter.TimestampType = conlog.TimestampTypeNone
+	options := conlog.NewAlertOptions(url)
+	options.LineFormatter = formatter
+	options.Lines = 3
+	options.Hostname = "host"
+	options.Program = "job"
+
+	return options
+}
+
+func newAlertLogger(t *testing.T, options *conlog.AlertOptions) (*conlog.Logger, *conlog.AlertHook) {
+	hook, err := conlog.NewAlertHook(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newHookLogger(hook), hook
+}
+
+func TestAlertHook_Fatal(t *testing.T) {
+	webhook := newAlertWebhook(t)
+	defer webhook.Close()
+	options := newTestAlertOptions(webhook.URL)
+	options.Channel = "ops"
+	log, _ := newAlertLogger(t, options)
+
+	log.Info("Starting job")
+	log.Info("Loading input")
+	log.Warn("Input is stale")
+	func() {
+		defer func() {
+			assert.Equal(t, conlog.Exit{Code: 3}, recover())
+		}()
+		log.FatalIfError(errors.New("no input"), 3, "Cannot load input")
+	}()
+
+	cmp := []string{"*FATAL* on host: job exited with code 3\n" +
+		"Cannot load input\n" +
+		"```\n" +
+		"level=info msg=\"Loading input\"\n" +
+		"level=warning msg=\"Input is stale\"\n" +
+		"level=fatal msg=\"Cannot load input\"\n" +
+		"```"}
+	t.Logf("out = %q", webhook.texts())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, webhook.texts())
+	assert.Equal(t, []string{"ops"}, webhook.channels)
+}
+
+func TestAlertHook_Template(t *testing.T) {
+	webhook := newAlertWebhook(t)
+	defer webhook.Close()
+	options := newTestAlertOptions(webhook.URL)
+	options.Template = `{{.Level}} {{.Message}} {{fields .Fields}} {{len .Lines}}`
+	options.Lines = 0
+	log, hook := newAlertLogger(t, options)
+
+	log.Info("Info test")
+	log.WithField("user", "bob").Error("Error test")
+
+	cmp := []string{"error Error test user=bob 0"}
+	t.Logf("out = %q", webhook.texts())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, webhook.texts())
+	assert.Equal(t, []conlog.Level{conlog.PanicLevel, conlog.FatalLevel, conlog.ErrorLevel}, hook.Levels())
+}
+
+func TestAlertHook_Suppression(t *testing.T) {
+	webhook := newAlertWebhook(t)
+	defer webhook.Close()
+	options := newTestAlertOptions(webhook.URL)
+	options.Template = `{{.Message}} ({{.Suppressed}})`
+	options.RateLimit = 2
+	log, hook := newAlertLogger(t, options)
+
+	log.Error("First error")
+	log.Error("First error")
+	log.Error("Second error")
+	log.Error("Third error")
+
+	cmp := []string{"First error (0)", "Second error (1)"}
+	t.Logf("out = %q", webhook.texts())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, webhook.texts())
+	assert.Equal(t, uint64(2), hook.Suppressed())
+}
+
+func TestAlertHook_StateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conlog-alert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	webhook := newAlertWebhook(t)
+	defer webhook.Close()
+	options := newTestAlertOptions(webhook.URL)
+	options.Template = `{{.Message}}`
+	options.StateFile = filepath.Join(dir, "alerts.json")
+
+	// Each hook stands in for a restart of a crashing program.
+	for i := 0; i < 3; i++ {
+		log, _ := newAlertLogger(t, options)
+		log.Error("Crash")
+	}
+	assert.Equal(t, []string{"Crash"}, webhook.texts())
+}
+
+func TestAlertHook_Loggers(t *testing.T) {
+	webhook := newAlertWebhook(t)
+	defer webhook.Close()
+	options := newTestAlertOptions(webhook.URL)
+	options.Template = `{{.Message}} {{.HasExitCode}} {{.ExitCode}}`
+	log, _ := newAlertLogger(t, options)
+	logs := conlog.NewLoggers(conlog.NewLoggers(log))
+
+	func() {
+		defer func() {
+			assert.Equal(t, conlog.Exit{Code: 4}, recover())
+		}()
+		logs.FatalfWithExitCode(4, "Fatal %s", "test")
+	}()
+	logs.FatalWithExitCode(-1, "No exit test")
+
+	cmp := []string{"Fatal test true 4", "No exit test false 0"}
+	t.Logf("out = %q", webhook.texts())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, webhook.texts())
+}
+
+func TestAlertHook_SlowWebhook(t *testing.T) {
+	release := make(chan struct{})
+	webhook := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer webhook.Close()
+	defer close(release)
+	options := newTestAlertOptions(webhook.URL)
+	options.SendTimeout = 50 * time.Millisecond
+	var errs int32
+	options.ErrorHandler = func(err error) {
+		atomic.AddInt32(&errs, 1)
+	}
+	log, _ := newAlertLogger(t, options)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		log.Error("Slow alert")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Logging is not blocked while the alert is being sent.
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		log.Info("Info test")
+	}()
+	select {
+	case <-logged:
+	case <-sent:
+		t.Error("alert was not sent concurrently")
+	}
+
+	// The alert gives up after the send timeout.
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert send was not bounded")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&errs))
+}
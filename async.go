@@ -0,0 +1,233 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy selects what happens to an entry logged when the
+// async queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowPolicyUnknown is an unknown policy.
+	OverflowPolicyUnknown OverflowPolicy = iota
+
+	// OverflowPolicyBlock waits for room in the queue.
+	OverflowPolicyBlock
+
+	// OverflowPolicyDropNewest drops the entry being logged.
+	OverflowPolicyDropNewest
+
+	// OverflowPolicyDropOldest drops the oldest queued entry to
+	// make room.
+	OverflowPolicyDropOldest
+
+	// OverflowPolicyDropBelowLevel drops the entry being logged if
+	// it is less severe than AsyncOptions.DropLevel and otherwise
+	// waits for room in the queue.
+	OverflowPolicyDropBelowLevel
+)
+
+// DefaultAsyncQueueSize is the default number of entries an async
+// logger queues.
+const DefaultAsyncQueueSize = 1024
+
+// AsyncOptions configure asynchronous logging. See
+// Logger.EnableAsync.
+type AsyncOptions struct {
+	// QueueSize is the number of entries queued before the
+	// Policy applies. The default is DefaultAsyncQueueSize.
+	QueueSize int
+
+	// Policy is the overflow policy. The default is
+	// OverflowPolicyBlock.
+	Policy OverflowPolicy
+
+	// DropLevel is the least severe level that is kept when the
+	// queue is full with OverflowPolicyDropBelowLevel, e.g.,
+	// WarnLevel drops Info, Debug, Trace, and Print entries.
+	DropLevel Level
+}
+
+// NewAsyncOptions is the constructor for AsyncOptions.
+func NewAsyncOptions() *AsyncOptions {
+	return &AsyncOptions{
+		QueueSize: DefaultAsyncQueueSize,
+		Policy:    OverflowPolicyBlock,
+		DropLevel: WarnLevel,
+	}
+}
+
+// asyncQueue is a bounded queue of entries drained by a writer
+// goroutine.
+type asyncQueue struct {
+	options AsyncOptions
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	entries  []Entry
+	inFlight int
+	closed   bool
+	dropped  *uint64
+	done     chan struct{}
+}
+
+// newAsyncQueue starts a queue that counts dropped entries in
+// dropped.
+func newAsyncQueue(options AsyncOptions, dropped *uint64) *asyncQueue {
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultAsyncQueueSize
+	}
+	if options.Policy == OverflowPolicyUnknown {
+		options.Policy = OverflowPolicyBlock
+	}
+	q := &asyncQueue{
+		options: options,
+		entries: make([]Entry, 0, options.QueueSize),
+		dropped: dropped,
+		done:    make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+
+	return q
+}
+
+// put queues a copy of entry, applying the overflow policy if the
+// queue is full. It returns false if the queue is closed and the
+// entry must be written synchronously.
+func (q *asyncQueue) put(entry *Entry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && len(q.entries) >= q.options.QueueSize {
+		switch q.options.Policy {
+		case OverflowPolicyDropNewest:
+			atomic.AddUint64(q.dropped, 1)
+			return true
+		case OverflowPolicyDropOldest:
+			q.entries = q.entries[1:]
+			atomic.AddUint64(q.dropped, 1)
+		case OverflowPolicyDropBelowLevel:
+			if entry.Level.severity() > q.options.DropLevel.severity() {
+				atomic.AddUint64(q.dropped, 1)
+				return true
+			}
+			q.cond.Wait()
+		default:
+			q.cond.Wait()
+		}
+	}
+	if q.closed {
+		return false
+	}
+	q.entries = append(q.entries, *entry)
+	q.cond.Broadcast()
+
+	return true
+}
+
+// flush waits until every queued entry has been written.
+func (q *asyncQueue) flush() {
+	q.mu.Lock()
+	for len(q.entries) > 0 || q.inFlight > 0 {
+		q.cond.Wait()
+	}
+	q.mu.Unlock()
+}
+
+// close writes the queued entries and stops the writer goroutine.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	<-q.done
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for {
+		q.mu.Lock()
+		for len(q.entries) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.entries) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		entries := q.entries
+		q.entries = make([]Entry, 0, q.options.QueueSize)
+		q.inFlight = len(entries)
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		for i := range entries {
+			entries[i].write(false)
+		}
+
+		q.mu.Lock()
+		q.inFlight = 0
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// EnableAsync makes the logger format and write entries in a
+// background goroutine so slow outputs do not stall the goroutines
+// that log. Entries are queued after the hooks have fired; what
+// happens when the queue is full is set by the overflow policy. A nil
+// options uses NewAsyncOptions(). Calling EnableAsync again replaces
+// the queue after writing the entries already queued.
+//
+// Fatal and Panic entries are written before the logging call
+// returns. Flush waits for the queue to be written, and Close also
+// returns the logger to synchronous logging. The logger is registered
+// with RegisterLogger so it is flushed by HandleExit.
+func (log *Logger) EnableAsync(options *AsyncOptions) {
+	if options == nil {
+		options = NewAsyncOptions()
+	}
+	log.asyncMu.Lock()
+	defer log.asyncMu.Unlock()
+
+	if q := log.asyncQueue(); q != nil {
+		q.close()
+	}
+	log.async.Store(newAsyncQueue(*options, &log.asyncDropped))
+	RegisterLogger(log)
+}
+
+// DisableAsync writes the queued entries and returns the logger to
+// synchronous logging.
+func (log *Logger) DisableAsync() {
+	log.asyncMu.Lock()
+	defer log.asyncMu.Unlock()
+
+	if q := log.asyncQueue(); q != nil {
+		log.async.Store((*asyncQueue)(nil))
+		q.close()
+	}
+}
+
+// IsAsync returns true if asynchronous logging is enabled.
+func (log *Logger) IsAsync() bool {
+	return log.asyncQueue() != nil
+}
+
+// Dropped returns the number of entries dropped by the overflow
+// policy since asynchronous logging was first enabled.
+func (log *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&log.asyncDropped)
+}
+
+func (log *Logger) asyncQueue() *asyncQueue {
+	q, _ := log.async.Load().(*asyncQueue)
+	return q
+}
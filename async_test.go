@@ -0,0 +1,209 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter blocks the first write until the gate is opened.
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	gate    chan struct{}
+	once    sync.Once
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		started: make(chan struct{}),
+		gate:    make(chan struct{}),
+	}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.gate
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+func newAsyncLogger(t *testing.T, w *gatedWriter, options *conlog.AsyncOptions) *conlog.Logger {
+	formatter, err := conlog.NewTemplateFormatter("{{.Message}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := conlog.NewLogger()
+	log.SetFormatter(formatter)
+	log.SetOutput(w)
+	log.SetErrorOutput(w)
+	log.EnableAsync(options)
+
+	return log
+}
+
+func TestLogger_AsyncPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  conlog.OverflowPolicy
+		cmp     string
+		dropped uint64
+	}{
+		{"Block", conlog.OverflowPolicyBlock, "1\n2\n3\n4\n5\n", 0},
+		{"DropNewest", conlog.OverflowPolicyDropNewest, "1\n2\n3\n", 2},
+		{"DropOldest", conlog.OverflowPolicyDropOldest, "1\n4\n5\n", 2},
+		{"DropBelowLevel", conlog.OverflowPolicyDropBelowLevel, "1\n2\n3\n5\n", 1},
+	}
+	for _, test := range tests {
+		w := newGatedWriter()
+		options := conlog.NewAsyncOptions()
+		options.QueueSize = 2
+		options.Policy = test.policy
+		options.DropLevel = conlog.WarnLevel
+		log := newAsyncLogger(t, w, options)
+
+		// The first entry blocks the writer goroutine, the next
+		// two fill the queue.
+		log.Info("1")
+		<-w.started
+		log.Info("2")
+		log.Info("3")
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info("4")
+			log.Warn("5")
+		}()
+		if test.policy == conlog.OverflowPolicyBlock || test.policy == conlog.OverflowPolicyDropBelowLevel {
+			time.Sleep(10 * time.Millisecond)
+		} else {
+			wg.Wait()
+		}
+		close(w.gate)
+		wg.Wait()
+		log.Flush()
+
+		t.Logf("%s: out = %q", test.name, w.String())
+		t.Logf("%s: cmp = %q", test.name, test.cmp)
+		assert.Equal(t, test.cmp, w.String(), test.name)
+		assert.Equal(t, test.dropped, log.Dropped(), test.name)
+		assert.NoError(t, log.Close())
+	}
+}
+
+func TestLogger_AsyncFatal(t *testing.T) {
+	w := newGatedWriter()
+	close(w.gate)
+	log := newAsyncLogger(t, w, nil)
+
+	log.Info("Info test")
+	func() {
+		defer func() {
+			assert.Equal(t, conlog.Exit{Code: 2}, recover())
+		}()
+		log.FatalWithExitCode(2, "Fatal test")
+	}()
+	cmp := "Info test\nFatal test\n"
+	t.Logf("out = %q", w.String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, w.String())
+	assert.NoError(t, log.Close())
+}
+
+func TestLogger_AsyncHandleExit(t *testing.T) {
+	w := newGatedWriter()
+	log := newAsyncLogger(t, w, nil)
+
+	func() {
+		defer conlog.HandleExit()
+		log.Info("1")
+		<-w.started
+		log.Info("2")
+		log.Info("3")
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(w.gate)
+		}()
+	}()
+	cmp := "1\n2\n3\n"
+	t.Logf("out = %q", w.String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, w.String())
+	assert.NoError(t, log.Close())
+}
+
+func TestLogger_AsyncClose(t *testing.T) {
+	w := newGatedWriter()
+	close(w.gate)
+	log := newAsyncLogger(t, w, nil)
+	assert.True(t, log.IsAsync())
+
+	for i := 0; i < 100; i++ {
+		log.Info("Async test")
+	}
+	assert.NoError(t, log.Close())
+	assert.False(t, log.IsAsync())
+	log.Info("Sync test")
+
+	cmp := strings.Repeat("Async test\n", 100) + "Sync test\n"
+	assert.Equal(t, cmp, w.String())
+}
+
+func TestLogger_AsyncFlushRace(t *testing.T) {
+	w := newBufferedOutput()
+	log := newBufferedLogger(t, w)
+	log.EnableAsync(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				log.Info("Race test")
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for flushing := true; flushing; {
+		select {
+		case <-done:
+			flushing = false
+		default:
+			assert.NoError(t, log.Flush())
+			log.SetOutput(w)
+		}
+	}
+	assert.NoError(t, log.Close())
+
+	cmp := strings.Repeat("Race test\n", 8000)
+	assert.Equal(t, cmp, w.buf.String())
+	assert.True(t, w.closed)
+}
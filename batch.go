@@ -0,0 +1,315 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults shared by the batching sinks.
+const (
+	// DefaultBatchSize is the default number of entries sent in
+	// one batch.
+	DefaultBatchSize = 100
+
+	// DefaultBatchInterval is the default longest time an entry
+	// waits before its batch is sent.
+	DefaultBatchInterval = time.Second
+
+	// DefaultQueueSize is the default number of entries buffered
+	// before new entries are dropped.
+	DefaultQueueSize = 1000
+
+	// DefaultMaxRetries is the default number of times a failed
+	// batch is retried.
+	DefaultMaxRetries = 5
+
+	// DefaultMinBackoff is the default delay before the first
+	// retry. It doubles with each retry up to DefaultMaxBackoff.
+	DefaultMinBackoff = 100 * time.Millisecond
+
+	// DefaultMaxBackoff is the default longest delay between
+	// retries.
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// batcher collects items from a bounded queue and hands them to send
+// in batches of up to size items, or at least every interval. If
+// maxBytes is set, []byte items are also batched so a batch holds at
+// most maxBytes bytes. Items added when the queue is full are dropped
+// and counted. send is only ever called from the batcher goroutine.
+type batcher struct {
+	size     int
+	maxBytes int
+	interval time.Duration
+	send     func(batch []interface{})
+
+	queue   chan interface{}
+	flushes chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	dropped uint64
+
+	// mu makes checking closed and queueing an item atomic with
+	// respect to close, so run drains every item that was queued.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newBatcher(queueSize int, size int, interval time.Duration, send func(batch []interface{})) *batcher {
+	return newSizedBatcher(queueSize, size, 0, interval, send)
+}
+
+// newSizedBatcher is newBatcher with a limit of maxBytes bytes per
+// batch of []byte items. A maxBytes of 0 is no limit.
+func newSizedBatcher(queueSize int, size int, maxBytes int, interval time.Duration, send func(batch []interface{})) *batcher {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if interval <= 0 {
+		interval = DefaultBatchInterval
+	}
+	b := &batcher{
+		size:     size,
+		maxBytes: maxBytes,
+		interval: interval,
+		send:     send,
+		queue:    make(chan interface{}, queueSize),
+		flushes:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go b.run()
+
+	return b
+}
+
+// add queues item without blocking. It returns false if the item was
+// dropped because the queue is full or the batcher is closed.
+func (b *batcher) add(item interface{}) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		atomic.AddUint64(&b.dropped, 1)
+		return false
+	}
+	select {
+	case b.queue <- item:
+		return true
+	default:
+		atomic.AddUint64(&b.dropped, 1)
+		return false
+	}
+}
+
+// flush sends all queued items and waits until they have been sent.
+func (b *batcher) flush() {
+	reply := make(chan struct{})
+	select {
+	case b.flushes <- reply:
+		<-reply
+	case <-b.stopped:
+	}
+}
+
+// close sends all queued items and stops the batcher.
+func (b *batcher) close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.mu.Unlock()
+	<-b.stopped
+}
+
+// droppedCount returns the number of items dropped.
+func (b *batcher) droppedCount() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+func (b *batcher) run() {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	batch := make([]interface{}, 0, b.size)
+	batchBytes := 0
+	sendBatch := func() {
+		if len(batch) > 0 {
+			b.send(batch)
+			batch = make([]interface{}, 0, b.size)
+			batchBytes = 0
+		}
+	}
+	addItem := func(item interface{}) {
+		if p, ok := item.([]byte); ok && b.maxBytes > 0 {
+			if batchBytes+len(p) > b.maxBytes {
+				sendBatch()
+			}
+			batchBytes += len(p)
+		}
+		batch = append(batch, item)
+		if len(batch) >= b.size || (b.maxBytes > 0 && batchBytes >= b.maxBytes) {
+			sendBatch()
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case item := <-b.queue:
+				addItem(item)
+			default:
+				sendBatch()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case item := <-b.queue:
+			addItem(item)
+		case <-ticker.C:
+			sendBatch()
+		case reply := <-b.flushes:
+			drain()
+			close(reply)
+		case <-b.done:
+			drain()
+			return
+		}
+	}
+}
+
+// handleError passes err to the ErrorHandler of a sink, or reports it
+// on stderr if there is none.
+func handleError(handler func(err error), err error) {
+	if handler != nil {
+		handler(err)
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+}
+
+// retrier retries a function with exponential backoff and jitter.
+type retrier struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func newRetrier(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) retrier {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+
+	return retrier{
+		maxRetries: maxRetries,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// do calls fn until it succeeds, returns a permanent error, or the
+// retries are used up. It returns the last error.
+func (r retrier) do(fn func() error) error {
+	return r.doContext(context.Background(), func(context.Context) error {
+		return fn()
+	})
+}
+
+// doContext is like do but also stops retrying when ctx is done.
+func (r retrier) doContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := r.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(permanentError); ok || attempt >= r.maxRetries {
+			return err
+		}
+		// Sleep between half and all of the backoff so
+		// clients that failed together do not retry together.
+		timer := time.NewTimer(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		backoff *= 2
+		if backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
+
+// permanentError wraps an error that retrying will not fix, e.g., an
+// HTTP 400 response.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+// postBatch POSTs body to url. Responses other than 2xx are errors;
+// 4xx responses other than 408 and 429 are permanent errors.
+func postBatch(client *http.Client, url string, header http.Header, body []byte) error {
+	return postBatchContext(context.Background(), client, url, header, body)
+}
+
+// postBatchContext is like postBatch but the request is canceled when
+// ctx is done.
+func postBatchContext(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req = req.WithContext(ctx)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+
+	return err
+}
@@ -53,53 +53,43 @@ const (
 	// verbose logging.
 	DebugLevel
 
-	// A pseudo-level. Print-level output is controlled by the
-	// PrintEnabled flag.
-	printLevel
+	// TraceLevel level. Designates even finer-grained informational
+	// events than Debug, e.g., wire dumps.
+	TraceLevel
+
+	// PrintLevel is a pseudo-level used for the Print*() family
+	// of logging functions. Print-level output is controlled by
+	// the PrintEnabled flag and the verbosity rather than the
+	// logging level. It is used to route Print*() output with
+	// SetLevelOutput.
+	PrintLevel
 )
 
 // String converts the Level to a string. E.g. PanicLevel becomes
 // "panic".
 func (level Level) String() string {
-	switch level {
-	case DebugLevel:
-		return "debug"
-	case InfoLevel:
-		return "info"
-	case WarnLevel:
-		return "warning"
-	case ErrorLevel:
-		return "error"
-	case FatalLevel:
-		return "fatal"
-	case PanicLevel:
-		return "panic"
+	if info := level.info(); info != nil {
+		return info.name
 	}
 	return "unknown"
 }
 
-// ParseLevel takes a string level and returns the log level constant.
+// ParseLevel takes a string level and returns the log level
+// constant. Levels added with RegisterLevel are also recognized.
 func ParseLevel(lvl string) (Level, error) {
-	switch strings.ToLower(lvl) {
-	case "panic":
-		return PanicLevel, nil
-	case "fatal":
-		return FatalLevel, nil
-	case "error":
-		return ErrorLevel, nil
-	case "warn", "warning":
+	if strings.ToLower(lvl) == "warn" {
 		return WarnLevel, nil
-	case "info":
-		return InfoLevel, nil
-	case "debug":
-		return DebugLevel, nil
+	}
+	if level, ok := lookupLevel(lvl); ok {
+		return level, nil
 	}
 
 	var l Level
 	return l, fmt.Errorf("Not a valid log Level: %q", lvl)
 }
 
-// AllLevels is a constant exposing all usable logging levels.
+// AllLevels is a constant exposing all built-in logging levels. Use
+// GetAllLevels to include the levels added with RegisterLevel.
 var AllLevels = []Level{
 	PanicLevel,
 	FatalLevel,
@@ -107,6 +97,7 @@ var AllLevels = []Level{
 	WarnLevel,
 	InfoLevel,
 	DebugLevel,
+	TraceLevel,
 }
 
 // The StdLogger interface is compatible with the standard library log package.
@@ -164,12 +155,16 @@ type ConLogger interface {
 	GetLevel() Level
 	SetPrintEnabled(enabled bool)
 	GetPrintEnabled() bool
+	SetVerbosity(verbosity int)
+	GetVerbosity() int
 	GetOutput() io.Writer
 	SetOutput(w io.Writer)
 	GetErrorOutput() io.Writer
 	SetErrorOutput(w io.Writer)
+	SetFormatter(formatter Formatter)
 
 	Printf(format string, args ...interface{})
+	Tracef(format string, args ...interface{})
 	Debugf(format string, args ...interface{})
 	Infof(format string, args ...interface{})
 	Warnf(format string, args ...interface{})
@@ -179,6 +174,7 @@ type ConLogger interface {
 	Panicf(format string, args ...interface{})
 
 	Print(args ...interface{})
+	Trace(args ...interface{})
 	Debug(args ...interface{})
 	Info(args ...interface{})
 	Warn(args ...interface{})
@@ -188,6 +184,7 @@ type ConLogger interface {
 	Panic(args ...interface{})
 
 	Println(args ...interface{})
+	Traceln(args ...interface{})
 	Debugln(args ...interface{})
 	Infoln(args ...interface{})
 	Warnln(args ...interface{})
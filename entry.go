@@ -6,9 +6,14 @@ package conlog
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,6 +24,14 @@ var (
 		"|1",
 	}
 	bufferPool *sync.Pool
+
+	// conlogPackage is the import path of this package. It is
+	// used to skip conlog frames when looking up the caller.
+	conlogPackage = reflect.TypeOf(Entry{}).PkgPath()
+
+	// ErrorKey is the key used by WithError to store the error in
+	// the entry's Fields.
+	ErrorKey = "error"
 )
 
 func init() {
@@ -45,12 +58,72 @@ func write(w io.Writer, s []byte) (n int, err error) {
 	return w.Write(s)
 }
 
+// maximumCallerDepth is the number of stack frames searched for the
+// first non-conlog caller.
+const maximumCallerDepth = 25
+
+// getPackageName reduces a fully qualified function name to the
+// package name, e.g., "github.com/a/b.(*T).f" becomes
+// "github.com/a/b".
+func getPackageName(f string) string {
+	for {
+		lastPeriod := strings.LastIndex(f, ".")
+		lastSlash := strings.LastIndex(f, "/")
+		if lastPeriod > lastSlash {
+			f = f[:lastPeriod]
+		} else {
+			break
+		}
+	}
+
+	return f
+}
+
+// getCaller returns the first stack frame outside of the conlog
+// package. This works regardless of whether the call came through
+// the standard logger, a Logger, an Entry, or Loggers.
+func getCaller() *runtime.Frame {
+	pcs := make([]uintptr, maximumCallerDepth)
+	depth := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:depth])
+	for {
+		frame, more := frames.Next()
+		if getPackageName(frame.Function) != conlogPackage {
+			return &frame
+		}
+		if !more {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Fields is the set of key/value pairs attached to an Entry using
+// WithField, WithFields, or WithError.
+type Fields map[string]interface{}
+
+// sortedKeys returns the keys of fields in lexical order.
+func (fields Fields) sortedKeys() []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
 // Entry is the final or intermediate logging entry. It's finally
 // logged when Debug, Info, Warn, Error, Fatal or Panic is called on
 // it.
 type Entry struct {
 	Log *Logger
 
+	// Fields contains all the key/value pairs set by the user
+	// with WithField, WithFields, and WithError.
+	Fields Fields
+
 	// Time at which the log entry was created.
 	Time time.Time
 
@@ -63,6 +136,19 @@ type Entry struct {
 	// Message passed to Debug, Info, Warn, Error, Fatal or Panic.
 	Message string
 
+	// Caller is the calling file, line, and function. It is only
+	// set when the logger has ReportCaller enabled.
+	Caller *runtime.Frame
+
+	// ExitCode is the code the program exits with after the entry
+	// is logged. It is only set for entries logged by the
+	// Fatal*() functions of Logger and Loggers that exit.
+	ExitCode *int
+
+	// Context is the context set with WithContext. Hooks use it
+	// to find request-scoped values such as trace and span IDs.
+	Context context.Context
+
 	// When formatter is called in entry.log(), a Buffer may be
 	// set to entry.
 	Buffer *bytes.Buffer
@@ -75,6 +161,63 @@ func NewEntry(log *Logger) *Entry {
 	}
 }
 
+// WithField returns a new Entry containing the fields of this entry
+// plus key set to value.
+func (entry *Entry) WithField(key string, value interface{}) *Entry {
+	return entry.WithFields(Fields{key: value})
+}
+
+// WithFields returns a new Entry containing the fields of this entry
+// plus fields. Keys in fields replace existing keys of the same
+// name.
+func (entry *Entry) WithFields(fields Fields) *Entry {
+	data := make(Fields, len(entry.Fields)+len(fields))
+	for k, v := range entry.Fields {
+		data[k] = v
+	}
+	for k, v := range fields {
+		data[k] = v
+	}
+
+	return &Entry{
+		Log:     entry.Log,
+		Fields:  data,
+		Context: entry.Context,
+	}
+}
+
+// WithContext returns a new Entry containing the fields of this entry
+// and ctx.
+func (entry *Entry) WithContext(ctx context.Context) *Entry {
+	data := make(Fields, len(entry.Fields))
+	for k, v := range entry.Fields {
+		data[k] = v
+	}
+
+	return &Entry{
+		Log:     entry.Log,
+		Fields:  data,
+		Context: ctx,
+	}
+}
+
+// WithError returns a new Entry containing the fields of this entry
+// plus err stored under ErrorKey.
+func (entry *Entry) WithError(err error) *Entry {
+	return entry.WithField(ErrorKey, err)
+}
+
+// HasExitCode returns true if the program exits after the entry is
+// logged.
+func (entry *Entry) HasExitCode() bool {
+	return entry.ExitCode != nil
+}
+
+// HasCaller returns true if the caller was recorded in the entry.
+func (entry *Entry) HasCaller() bool {
+	return entry.Caller != nil
+}
+
 // String returns the string representation from the reader and
 // ultimately the formatter.
 func (entry *Entry) String() (string, error) {
@@ -86,71 +229,138 @@ func (entry *Entry) String() (string, error) {
 	return str, nil
 }
 
-// log outputs the message to the Writer after formatting it. It is
-// not declared with a pointer value because otherwise race conditions
-// will occur when using multiple goroutines.
-func (entry Entry) log(level Level, w io.Writer, msg string) {
-	var buffer *bytes.Buffer
+// log outputs the message to the Writer after formatting it and then
+// panics if level is PanicLevel. It is not declared with a pointer
+// value because otherwise race conditions will occur when using
+// multiple goroutines.
+func (entry Entry) log(level Level, msg string) {
+	entry.output(level, msg)
+
+	// To avoid Entry#log() returning a value that only would make sense for
+	// panic() to use in Entry#Panic(), we avoid the allocation by checking
+	// directly here.
+	if level <= PanicLevel {
+		panic(&entry)
+	}
+}
+
+// logNoExit outputs the message like log but never panics, even at
+// PanicLevel.
+func (entry Entry) logNoExit(level Level, msg string) {
+	entry.output(level, msg)
+}
+
+// output fills in the entry, fires its hooks, and writes it, or
+// queues it if the logger is asynchronous.
+func (entry *Entry) output(level Level, msg string) {
 	entry.Time = time.Now()
 	entry.Level = level
 	entry.Message = msg
+	if entry.Log.GetReportCaller() {
+		entry.Caller = getCaller()
+	}
 
-	buffer = bufferPool.Get().(*bytes.Buffer)
+	if !entry.fireHooks() {
+		return
+	}
+
+	if q := entry.Log.asyncQueue(); q != nil {
+		if q.put(entry) {
+			// Fatal and Panic entries usually end the
+			// program so they are written before returning.
+			if level.severity() <= FatalLevel {
+				q.flush()
+			}
+			return
+		}
+		// The queue is closing; let it finish writing so
+		// entries are not written concurrently.
+		<-q.done
+	}
+	entry.write(true)
+}
+
+// write formats the entry and writes it to the writer for its level
+// holding the logger write mutex, which also keeps the outputs the
+// formatter inspects, e.g., to detect a terminal, from being replaced.
+// If holdLock is false the logger mutex is only held to look up the
+// formatter and the writer. The writer goroutine of an async logger
+// writes this way so a slow writer does not block the goroutines that
+// log, which only need the logger mutex to fire hooks.
+func (entry *Entry) write(holdLock bool) {
+	entry.Log.writeMu.Lock()
+	defer entry.Log.writeMu.Unlock()
+	entry.Log.mu.Lock()
+	formatter := entry.Log.formatter
+	entry.Log.mu.Unlock()
+
+	buffer := bufferPool.Get().(*bytes.Buffer)
 	buffer.Reset()
 	defer bufferPool.Put(buffer)
 	entry.Buffer = buffer
-	serialized, err := entry.Log.formatter.Format(&entry)
+	serialized, err := formatter.Format(entry)
 	entry.Buffer = nil
 	if err != nil {
 		entry.Log.mu.Lock()
 		_, _ = fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
 		entry.Log.mu.Unlock()
-	} else {
-		entry.Log.mu.Lock()
-		_, err = write(w, serialized)
-		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
-		}
-		entry.Log.mu.Unlock()
+		return
+	}
+	if len(serialized) == 0 {
+		return
 	}
 
-	// To avoid Entry#log() returning a value that only would make sense for
-	// panic() to use in Entry#Panic(), we avoid the allocation by checking
-	// directly here.
-	if level <= PanicLevel {
-		panic(&entry)
+	entry.Log.mu.Lock()
+	w := entry.Log.writerFor(entry.Level)
+	if !holdLock {
+		entry.Log.mu.Unlock()
+	}
+	_, err = write(w, serialized)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+	if holdLock {
+		entry.Log.mu.Unlock()
 	}
 }
 
 // Print writes a message ala fmt.Print if printing is enabled,
 // otherwise it is discarded.
 func (entry *Entry) Print(args ...interface{}) {
-	if entry.Log.GetPrintEnabled() {
-		entry.log(printLevel, entry.Log.out, fmt.Sprint(args...))
+	if entry.Log.isPrintEnabled(0) {
+		entry.log(PrintLevel, fmt.Sprint(args...))
+	}
+}
+
+// Trace writes a message ala fmt.Print.
+func (entry *Entry) Trace(args ...interface{}) {
+	if entry.Log.IsLevelEnabled(TraceLevel) {
+		args = append(args, "\n")
+		entry.log(TraceLevel, fmt.Sprint(args...))
 	}
 }
 
 // Debug writes a message ala fmt.Print.
 func (entry *Entry) Debug(args ...interface{}) {
-	if entry.Log.GetLevel() >= DebugLevel {
+	if entry.Log.IsLevelEnabled(DebugLevel) {
 		args = append(args, "\n")
-		entry.log(DebugLevel, entry.Log.out, fmt.Sprint(args...))
+		entry.log(DebugLevel, fmt.Sprint(args...))
 	}
 }
 
 // Info writes a message ala fmt.Print.
 func (entry *Entry) Info(args ...interface{}) {
-	if entry.Log.GetLevel() >= InfoLevel {
+	if entry.Log.IsLevelEnabled(InfoLevel) {
 		args = append(args, "\n")
-		entry.log(InfoLevel, entry.Log.out, fmt.Sprint(args...))
+		entry.log(InfoLevel, fmt.Sprint(args...))
 	}
 }
 
 // Warn writes a message ala fmt.Print.
 func (entry *Entry) Warn(args ...interface{}) {
-	if entry.Log.GetLevel() >= WarnLevel {
+	if entry.Log.IsLevelEnabled(WarnLevel) {
 		args = append(args, "\n")
-		entry.log(WarnLevel, entry.Log.out, fmt.Sprint(args...))
+		entry.log(WarnLevel, fmt.Sprint(args...))
 	}
 }
 
@@ -161,25 +371,25 @@ func (entry *Entry) Warning(args ...interface{}) {
 
 // Error writes a message ala fmt.Print.
 func (entry *Entry) Error(args ...interface{}) {
-	if entry.Log.GetLevel() >= ErrorLevel {
+	if entry.Log.IsLevelEnabled(ErrorLevel) {
 		args = append(args, "\n")
-		entry.log(ErrorLevel, entry.Log.errOut, fmt.Sprint(args...))
+		entry.log(ErrorLevel, fmt.Sprint(args...))
 	}
 }
 
 // Fatal writes a message ala fmt.Print.
 func (entry *Entry) Fatal(args ...interface{}) {
-	if entry.Log.GetLevel() >= FatalLevel {
+	if entry.Log.IsLevelEnabled(FatalLevel) {
 		args = append(args, "\n")
-		entry.log(FatalLevel, entry.Log.errOut, fmt.Sprint(args...))
+		entry.log(FatalLevel, fmt.Sprint(args...))
 	}
 }
 
 // Panic writes a message ala fmt.Print and then calls panic.
 func (entry *Entry) Panic(args ...interface{}) {
-	if entry.Log.GetLevel() >= PanicLevel {
+	if entry.Log.IsLevelEnabled(PanicLevel) {
 		args = append(args, "\n")
-		entry.log(PanicLevel, entry.Log.errOut, fmt.Sprint(args...))
+		entry.log(PanicLevel, fmt.Sprint(args...))
 	}
 	panic(fmt.Sprint(args...))
 }
@@ -189,30 +399,40 @@ func (entry *Entry) Panic(args ...interface{}) {
 // Printf writes a message ala fmt.Printf if printing is enabled,
 // otherwise it is discarded. No newline is appended.
 func (entry *Entry) Printf(format string, args ...interface{}) {
-	entry.log(printLevel, entry.Log.out, fmt.Sprintf(format, args...))
+	if entry.Log.isPrintEnabled(0) {
+		entry.log(PrintLevel, fmt.Sprintf(format, args...))
+	}
+}
+
+// Tracef writes a message ala fmt.Printf.
+func (entry *Entry) Tracef(format string, args ...interface{}) {
+	if entry.Log.IsLevelEnabled(TraceLevel) {
+		format += "\n"
+		entry.log(TraceLevel, fmt.Sprintf(format, args...))
+	}
 }
 
 // Debugf writes a message ala fmt.Printf.
 func (entry *Entry) Debugf(format string, args ...interface{}) {
-	if entry.Log.GetLevel() >= DebugLevel {
+	if entry.Log.IsLevelEnabled(DebugLevel) {
 		format += "\n"
-		entry.log(DebugLevel, entry.Log.out, fmt.Sprintf(format, args...))
+		entry.log(DebugLevel, fmt.Sprintf(format, args...))
 	}
 }
 
 // Infof writes a message ala fmt.Printf.
 func (entry *Entry) Infof(format string, args ...interface{}) {
-	if entry.Log.GetLevel() >= InfoLevel {
+	if entry.Log.IsLevelEnabled(InfoLevel) {
 		format += "\n"
-		entry.log(InfoLevel, entry.Log.out, fmt.Sprintf(format, args...))
+		entry.log(InfoLevel, fmt.Sprintf(format, args...))
 	}
 }
 
 // Warnf writes a message ala fmt.Printf.
 func (entry *Entry) Warnf(format string, args ...interface{}) {
-	if entry.Log.GetLevel() >= WarnLevel {
+	if entry.Log.IsLevelEnabled(WarnLevel) {
 		format += "\n"
-		entry.log(WarnLevel, entry.Log.out, fmt.Sprintf(format, args...))
+		entry.log(WarnLevel, fmt.Sprintf(format, args...))
 	}
 }
 
@@ -223,25 +443,25 @@ func (entry *Entry) Warningf(format string, args ...interface{}) {
 
 // Errorf writes a message ala fmt.Printf.
 func (entry *Entry) Errorf(format string, args ...interface{}) {
-	if entry.Log.GetLevel() >= ErrorLevel {
+	if entry.Log.IsLevelEnabled(ErrorLevel) {
 		format += "\n"
-		entry.log(ErrorLevel, entry.Log.errOut, fmt.Sprintf(format, args...))
+		entry.log(ErrorLevel, fmt.Sprintf(format, args...))
 	}
 }
 
 // Fatalf writes a message ala fmt.Printf.
 func (entry *Entry) Fatalf(format string, args ...interface{}) {
-	if entry.Log.GetLevel() >= FatalLevel {
+	if entry.Log.IsLevelEnabled(FatalLevel) {
 		format += "\n"
-		entry.log(FatalLevel, entry.Log.errOut, fmt.Sprintf(format, args...))
+		entry.log(FatalLevel, fmt.Sprintf(format, args...))
 	}
 }
 
 // Panicf writes a message ala fmt.Print and then calls panicf.
 func (entry *Entry) Panicf(format string, args ...interface{}) {
-	if entry.Log.GetLevel() >= PanicLevel {
+	if entry.Log.IsLevelEnabled(PanicLevel) {
 		format += "\n"
-		entry.log(PanicLevel, entry.Log.errOut, fmt.Sprintf(format, args...))
+		entry.log(PanicLevel, fmt.Sprintf(format, args...))
 	}
 }
 
@@ -250,30 +470,37 @@ func (entry *Entry) Panicf(format string, args ...interface{}) {
 // Println writes a message ala fmt.Println if printing is enabled,
 // otherwise it is discarded.
 func (entry *Entry) Println(args ...interface{}) {
-	if entry.Log.GetPrintEnabled() {
+	if entry.Log.isPrintEnabled(0) {
 		msg := fmt.Sprintln(args...)
 		entry.Print(msg)
 	}
 }
 
+// Traceln writes a message ala fmt.Println.
+func (entry *Entry) Traceln(args ...interface{}) {
+	if entry.Log.IsLevelEnabled(TraceLevel) {
+		entry.log(TraceLevel, fmt.Sprintln(args...))
+	}
+}
+
 // Debugln writes a message ala fmt.Println.
 func (entry *Entry) Debugln(args ...interface{}) {
-	if entry.Log.GetLevel() >= DebugLevel {
-		entry.log(DebugLevel, entry.Log.out, fmt.Sprintln(args...))
+	if entry.Log.IsLevelEnabled(DebugLevel) {
+		entry.log(DebugLevel, fmt.Sprintln(args...))
 	}
 }
 
 // Infoln writes a message ala fmt.Println.
 func (entry *Entry) Infoln(args ...interface{}) {
-	if entry.Log.GetLevel() >= InfoLevel {
-		entry.log(InfoLevel, entry.Log.out, fmt.Sprintln(args...))
+	if entry.Log.IsLevelEnabled(InfoLevel) {
+		entry.log(InfoLevel, fmt.Sprintln(args...))
 	}
 }
 
 // Warnln writes a message ala fmt.Println.
 func (entry *Entry) Warnln(args ...interface{}) {
-	if entry.Log.GetLevel() >= WarnLevel {
-		entry.log(WarnLevel, entry.Log.out, fmt.Sprintln(args...))
+	if entry.Log.IsLevelEnabled(WarnLevel) {
+		entry.log(WarnLevel, fmt.Sprintln(args...))
 	}
 }
 
@@ -285,21 +512,21 @@ func (entry *Entry) Warningln(args ...interface{}) {
 
 // Errorln writes a message ala fmt.Println.
 func (entry *Entry) Errorln(args ...interface{}) {
-	if entry.Log.GetLevel() >= ErrorLevel {
-		entry.log(ErrorLevel, entry.Log.errOut, fmt.Sprintln(args...))
+	if entry.Log.IsLevelEnabled(ErrorLevel) {
+		entry.log(ErrorLevel, fmt.Sprintln(args...))
 	}
 }
 
 // Fatalln writes a message ala fmt.Println.
 func (entry *Entry) Fatalln(args ...interface{}) {
-	if entry.Log.GetLevel() >= FatalLevel {
-		entry.log(FatalLevel, entry.Log.errOut, fmt.Sprintln(args...))
+	if entry.Log.IsLevelEnabled(FatalLevel) {
+		entry.log(FatalLevel, fmt.Sprintln(args...))
 	}
 }
 
 // Panicln writes a message ala fmt.Println and then calls panic.
 func (entry *Entry) Panicln(args ...interface{}) {
-	if entry.Log.GetLevel() >= PanicLevel {
-		entry.log(PanicLevel, entry.Log.errOut, fmt.Sprintln(args...))
+	if entry.Log.IsLevelEnabled(PanicLevel) {
+		entry.log(PanicLevel, fmt.Sprintln(args...))
 	}
 }
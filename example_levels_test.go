@@ -0,0 +1,48 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"os"
+
+	"github.com/apatters/go-conlog"
+)
+
+// User-defined levels are usually registered once when the program
+// starts. Each level has a name, the built-in level it is filtered
+// and routed like, an ANSI color code, and a short label.
+var (
+	noticeLevel  = conlog.RegisterLevel("notice", conlog.InfoLevel, 36, "NOTI")
+	successLevel = conlog.RegisterLevel("success", conlog.InfoLevel, 32, "✔")
+)
+
+func ExampleRegisterLevel() {
+	log := conlog.NewLogger()
+	log.SetErrorOutput(os.Stdout) // All output goes to stdout for this example.
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.LogLevelFmt = conlog.LogLevelFormatShort
+	log.SetFormatter(formatter)
+
+	// Use the Log* methods to output messages at a user-defined
+	// level.
+	log.Log(noticeLevel, "Configuration reloaded.")
+	log.Logf(successLevel, "Copied %d files.", 3)
+
+	// User-defined levels are filtered like their severity, so
+	// these messages are suppressed when the logger is at
+	// WarnLevel.
+	log.SetLevel(conlog.WarnLevel)
+	log.Log(successLevel, "This message is suppressed.")
+
+	// User-defined levels can be parsed like the built-in ones.
+	level, _ := conlog.ParseLevel("SUCCESS")
+	log.SetLevel(level)
+	log.Log(successLevel, "Done.")
+
+	// Output:
+	// NOTI Configuration reloaded.
+	// ✔ Copied 3 files.
+	// ✔ Done.
+}
@@ -25,6 +25,7 @@ func ExampleLogger() {
 	// WarnLevel
 	// InfoLevel
 	// DebugLevel
+	// TraceLevel
 	log.SetLevel(conlog.DebugLevel)
 	log.SetErrorOutput(os.Stdout) // All output goes to stdout.
 	formatter := conlog.NewStdFormatter()
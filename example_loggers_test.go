@@ -56,22 +56,32 @@ func ExampleLoggers() {
 	bothLogs.SetLevel(conlog.DebugLevel)
 	bothLogs.Debug("This debug message goes to both the TTY and the log file now that they are DebugLevel.")
 
+	// A filter with a level floor keeps the log file at DebugLevel
+	// when the level of all logs is lowered.
+	bothLogs.SetFilter(fileLog, &conlog.Filter{LevelFloor: conlog.DebugLevel})
+	bothLogs.SetLevel(conlog.InfoLevel)
+	bothLogs.Debug("This debug message only goes to the log file because of its floor.")
+
+	// SetLevelOf only changes the level of the given logs.
+	bothLogs.SetLevelOf(conlog.WarnLevel, ttyLog)
+	bothLogs.Info("This info message only goes to the log file now that the TTY is WarnLevel.")
+	bothLogs.SetLevelOf(conlog.InfoLevel, ttyLog)
+
 	// We can enable/disable Print* methods for all logs using SetPrintEnabled.
 	bothLogs.SetPrintEnabled(false)
 	bothLogs.Print("This message is suppressed on both the TTY and the log file.")
 	bothLogs.SetPrintEnabled(true)
 	bothLogs.Print("This message goes to both TTY and the log file now that Print is re-enabled.")
 
-	// We can use Fatal* and Panic* methods, but the messages will
-	// only go the first log as the program will terminate before
-	// getting to subsequent logs.
+	// We can use Fatal* and Panic* methods. The message goes to
+	// all logs before the program exits or panics once.
 	var err error
 	if err != nil {
-		bothLogs.Fatal("This fatal message only goes to the TTY.")
+		bothLogs.Fatal("This fatal message goes to both the TTY and the log file.")
 	}
 	var impossibleCond bool
 	if impossibleCond {
-		bothLogs.Panic("This panic message only goes to the TTY. The panic() output always goes to stderr.")
+		bothLogs.Panic("This panic message goes to both logs. The panic() output always goes to stderr.")
 	}
 
 	// Output:
@@ -53,3 +53,20 @@ func ExampleLogger_Print() {
 	// Printing enabled: true
 	// Print* messages are no longer suppressed.
 }
+
+// The Print* methods can be split into verbosity tiers using V. A
+// message printed with V(n) is only output if the verbosity is at
+// least n. Plain Print* methods are tier 0. Command-line programs
+// typically map -v, -vv, and -vvv onto verbosities 1, 2, and 3.
+func ExampleLogger_V() {
+	log := conlog.NewLogger()
+
+	log.SetVerbosity(1) // As if -v was passed.
+	log.Println("Always printed.")
+	log.V(1).Println("Printed with -v or higher.")
+	log.V(2).Println("Printed with -vv or higher.")
+
+	// Output:
+	// Always printed.
+	// Printed with -v or higher.
+}
@@ -0,0 +1,360 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FluentMode selects the Fluent forward protocol event mode.
+type FluentMode int
+
+const (
+	// FluentModeUnknown is an unknown mode.
+	FluentModeUnknown FluentMode = iota
+
+	// FluentModeForward sends a batch as an array of [time,
+	// record] entries.
+	FluentModeForward
+
+	// FluentModePackedForward sends a batch as a binary holding the
+	// concatenated [time, record] entries, which the receiver can
+	// store without decoding.
+	FluentModePackedForward
+)
+
+const (
+	// DefaultFluentTag is the default tag of forwarded events.
+	DefaultFluentTag = "conlog"
+
+	// DefaultFluentTimeout is the default timeout for connecting,
+	// writing a batch, and waiting for its acknowledgement.
+	DefaultFluentTimeout = 5 * time.Second
+)
+
+// FluentOptions configure a FluentSink.
+type FluentOptions struct {
+	// Network is "tcp" (or its 4/6 variants) or "unix".
+	Network string
+
+	// Addr is the address of the forward input, e.g.,
+	// "localhost:24224" or "/var/run/fluent.sock".
+	Addr string
+
+	// Tag is the tag events are routed by. The default is
+	// DefaultFluentTag.
+	Tag string
+
+	// Mode is the event mode. The default is FluentModeForward.
+	Mode FluentMode
+
+	// RequireAck sends a chunk id with each batch and waits for
+	// the receiver to acknowledge it. A batch that is not
+	// acknowledged is resent.
+	RequireAck bool
+
+	// DisableEventTime sends times as integer seconds instead of
+	// the EventTime extension. Set it for receivers older than
+	// Fluentd v0.14.
+	DisableEventTime bool
+
+	// FieldMap allows users to customize the names of the record
+	// keys for the default fields.
+	FieldMap FieldMap
+
+	// Levels are the levels forwarded. The default is every level
+	// returned by GetAllLevels.
+	Levels []Level
+
+	// BatchSize, BatchInterval, and QueueSize control batching.
+	// Entries arriving when the queue is full are dropped and
+	// counted. QueueSize also bounds the entries kept while the
+	// receiver is unreachable. The defaults are DefaultBatchSize,
+	// DefaultBatchInterval, and DefaultQueueSize.
+	BatchSize     int
+	BatchInterval time.Duration
+	QueueSize     int
+
+	// MaxRetries, MinBackoff, and MaxBackoff control reconnecting
+	// and resending a failed batch. A batch that still fails is
+	// kept and sent ahead of the next one. The defaults are
+	// DefaultMaxRetries, DefaultMinBackoff, and DefaultMaxBackoff.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Timeout bounds connecting, writing a batch, and waiting for
+	// its acknowledgement. The default is DefaultFluentTimeout.
+	Timeout time.Duration
+
+	// ErrorHandler is called when a batch cannot be sent. The
+	// default writes the error to stderr.
+	ErrorHandler func(err error)
+}
+
+// NewFluentOptions is the constructor for FluentOptions.
+func NewFluentOptions(network string, addr string) *FluentOptions {
+	return &FluentOptions{
+		Network:       network,
+		Addr:          addr,
+		Tag:           DefaultFluentTag,
+		Mode:          FluentModeForward,
+		BatchSize:     DefaultBatchSize,
+		BatchInterval: DefaultBatchInterval,
+		QueueSize:     DefaultQueueSize,
+		MaxRetries:    DefaultMaxRetries,
+		MinBackoff:    DefaultMinBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		Timeout:       DefaultFluentTimeout,
+	}
+}
+
+// FluentSink is a Hook that batches entries and sends them to a
+// Fluentd or Fluent Bit forward input. Add it to a logger with
+// AddHook:
+//
+//	sink, err := conlog.NewFluentSink(conlog.NewFluentOptions("tcp", "localhost:24224"))
+//	log.AddHook(sink)
+//	defer sink.Close()
+//
+// Each record holds the entry level, message, fields, and caller; the
+// entry time is the event time. The connection is made on the first
+// batch and remade after errors. Until it is closed, the sink is
+// flushed by HandleExit, which waits for acks if RequireAck is set.
+type FluentSink struct {
+	options FluentOptions
+	batcher *batcher
+	retrier retrier
+
+	// pending holds the entries of batches that could not be sent.
+	// It is only used by the batcher goroutine.
+	pending [][]byte
+	dropped uint64
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+var _ Hook = (*FluentSink)(nil)
+
+// NewFluentSink is the constructor for FluentSink. A nil options is an
+// error as the address is required.
+func NewFluentSink(options *FluentOptions) (*FluentSink, error) {
+	if options == nil || options.Addr == "" {
+		return nil, fmt.Errorf("Fluent forward address is required")
+	}
+	switch options.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, fmt.Errorf("unsupported Fluent forward network %q", options.Network)
+	}
+	s := &FluentSink{options: *options}
+	if s.options.Tag == "" {
+		s.options.Tag = DefaultFluentTag
+	}
+	if s.options.Mode == FluentModeUnknown {
+		s.options.Mode = FluentModeForward
+	}
+	if s.options.Levels == nil {
+		s.options.Levels = GetAllLevels()
+	}
+	if s.options.QueueSize <= 0 {
+		s.options.QueueSize = DefaultQueueSize
+	}
+	if s.options.Timeout <= 0 {
+		s.options.Timeout = DefaultFluentTimeout
+	}
+	s.retrier = newRetrier(s.options.MaxRetries, s.options.MinBackoff, s.options.MaxBackoff)
+	s.batcher = newBatcher(s.options.QueueSize, s.options.BatchSize, s.options.BatchInterval, s.push)
+	registerHook(s)
+
+	return s, nil
+}
+
+// Levels returns the levels the sink is fired for.
+func (s *FluentSink) Levels() []Level {
+	return s.options.Levels
+}
+
+// Fire encodes the entry as a Forward event and queues it for the
+// next forward to Addr. It does not wait for the connection; if
+// QueueSize events are already queued the entry is dropped and
+// counted by Dropped.
+func (s *FluentSink) Fire(entry *Entry) error {
+	record := make(Fields, len(entry.Fields)+4)
+	for k, v := range entry.Fields {
+		record[k] = v
+	}
+	prefixFieldClashes(record, s.options.FieldMap)
+	record[s.options.FieldMap.resolve(FieldKeyMsg)] = strings.TrimSuffix(entry.Message, "\n")
+	record[s.options.FieldMap.resolve(FieldKeyLevel)] = entry.Level.String()
+	if entry.HasCaller() {
+		record[s.options.FieldMap.resolve(FieldKeyFunc)] = entry.Caller.Function
+		record[s.options.FieldMap.resolve(FieldKeyFile)] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+
+	b := msgpackAppendArrayHeader(nil, 2)
+	if s.options.DisableEventTime {
+		b = msgpackAppendInt(b, entry.Time.Unix())
+	} else {
+		b = msgpackAppendEventTime(b, entry.Time)
+	}
+	s.batcher.add(msgpackAppend(b, record))
+
+	return nil
+}
+
+// Flush sends all queued entries and waits for the send to finish.
+func (s *FluentSink) Flush() {
+	s.batcher.flush()
+}
+
+// Close sends all queued entries, stops the sink, and closes the
+// connection. Entries that still cannot be sent, and entries fired
+// after Close, are dropped.
+func (s *FluentSink) Close() error {
+	unregisterHook(s)
+	s.batcher.close()
+	if len(s.pending) > 0 {
+		atomic.AddUint64(&s.dropped, uint64(len(s.pending)))
+		s.pending = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// Dropped returns the number of entries dropped because the queue was
+// full, the receiver was unreachable for too long, or the sink was
+// closed.
+func (s *FluentSink) Dropped() uint64 {
+	return s.batcher.droppedCount() + atomic.LoadUint64(&s.dropped)
+}
+
+// push sends the pending entries and a new batch. It is called by the
+// batcher.
+func (s *FluentSink) push(batch []interface{}) {
+	entries := s.pending
+	for _, item := range batch {
+		entries = append(entries, item.([]byte))
+	}
+	s.pending = nil
+
+	var chunk string
+	if s.options.RequireAck {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			handleError(s.options.ErrorHandler, fmt.Errorf("failed to generate Fluent chunk id, %v", err))
+			return
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	msg := s.encode(entries, chunk)
+	err := s.retrier.do(func() error {
+		return s.send(msg, chunk)
+	})
+	if err == nil {
+		return
+	}
+
+	// Keep the newest entries for the next attempt.
+	if over := len(entries) - s.options.QueueSize; over > 0 {
+		atomic.AddUint64(&s.dropped, uint64(over))
+		entries = entries[over:]
+	}
+	s.pending = entries
+	handleError(s.options.ErrorHandler, fmt.Errorf("failed to forward %d entries to %s, %v", len(entries), s.options.Addr, err))
+}
+
+// encode builds a Forward or PackedForward message:
+//
+//	[tag, [[time, record], ...], option]
+//	[tag, bin([time, record][time, record]...), option]
+func (s *FluentSink) encode(entries [][]byte, chunk string) []byte {
+	option := map[string]interface{}{"size": len(entries)}
+	if chunk != "" {
+		option["chunk"] = chunk
+	}
+
+	var b []byte
+	b = msgpackAppendArrayHeader(b, 3)
+	b = msgpackAppendString(b, s.options.Tag)
+	if s.options.Mode == FluentModePackedForward {
+		var packed []byte
+		for _, e := range entries {
+			packed = append(packed, e...)
+		}
+		b = msgpackAppendBinary(b, packed)
+	} else {
+		b = msgpackAppendArrayHeader(b, len(entries))
+		for _, e := range entries {
+			b = append(b, e...)
+		}
+	}
+
+	return msgpackAppendMap(b, option)
+}
+
+// send writes msg, connecting first if needed, and waits for the
+// acknowledgement of chunk if it is set. Any error closes the
+// connection so the next attempt reconnects.
+func (s *FluentSink) send(msg []byte, chunk string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.options.Network, s.options.Addr, s.options.Timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.reader = bufio.NewReader(conn)
+	}
+	err := s.sendLocked(msg, chunk)
+	if err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+
+	return err
+}
+
+func (s *FluentSink) sendLocked(msg []byte, chunk string) error {
+	if err := s.conn.SetDeadline(time.Now().Add(s.options.Timeout)); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	resp, err := msgpackDecode(s.reader)
+	if err != nil {
+		return fmt.Errorf("failed to read acknowledgement, %v", err)
+	}
+	if m, ok := resp.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return fmt.Errorf("unexpected acknowledgement %v", resp)
+	}
+
+	return nil
+}
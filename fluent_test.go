@@ -0,0 +1,333 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeMsgpack decodes one MessagePack value. EventTimes are
+// returned as time.Time and binaries as []byte.
+func decodeMsgpack(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	readN := func(n int) ([]byte, error) {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+	readLen := func(size int) (int, error) {
+		buf, err := readN(size)
+		if err != nil {
+			return 0, err
+		}
+		n := 0
+		for _, b := range buf {
+			n = n<<8 | int(b)
+		}
+		return n, nil
+	}
+	decodeArray := func(n int) (interface{}, error) {
+		a := make([]interface{}, n)
+		for i := range a {
+			if a[i], err = decodeMsgpack(r); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	}
+	decodeMap := func(n int) (interface{}, error) {
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			k, err := decodeMsgpack(r)
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(k)], err = decodeMsgpack(r); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c&0xf0 == 0x80:
+		return decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		buf, err := readN(int(c & 0x1f))
+		return string(buf), err
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2, 0xc3:
+		return c == 0xc3, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readLen(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readN(n)
+	case 0xd9, 0xda, 0xdb:
+		n, err := readLen(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		buf, err := readN(n)
+		return string(buf), err
+	case 0xcc, 0xcd, 0xce:
+		n, err := readLen(1 << (c - 0xcc))
+		return int64(n), err
+	case 0xd7:
+		buf, err := readN(9)
+		if err != nil || buf[0] != 0 {
+			return nil, fmt.Errorf("bad EventTime")
+		}
+		sec := binary.BigEndian.Uint32(buf[1:])
+		nsec := binary.BigEndian.Uint32(buf[5:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	case 0xdc:
+		n, err := readLen(2)
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(n)
+	case 0xde:
+		n, err := readLen(2)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(n)
+	}
+
+	return nil, fmt.Errorf("unsupported msgpack type %#x", c)
+}
+
+// fluentEvent is a decoded forwarded event.
+type fluentEvent struct {
+	Tag    string
+	Time   interface{}
+	Record map[string]interface{}
+}
+
+// fluentServer records the events forwarded to it. It drops the
+// first failures connections without acknowledging them.
+type fluentServer struct {
+	net.Listener
+	mu       sync.Mutex
+	events   []fluentEvent
+	failures int
+}
+
+func newFluentServer(t *testing.T, addr string, failures int) *fluentServer {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fluentServer{Listener: l, failures: failures}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(t, conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fluentServer) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := decodeMsgpack(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		fail := s.failures > 0
+		s.failures--
+		s.mu.Unlock()
+		if fail {
+			return
+		}
+
+		a := msg.([]interface{})
+		tag := a[0].(string)
+		option := a[2].(map[string]interface{})
+		var entries []interface{}
+		switch v := a[1].(type) {
+		case []interface{}:
+			entries = v
+		case []byte:
+			packed := bufio.NewReader(bytes.NewReader(v))
+			for {
+				e, err := decodeMsgpack(packed)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Errorf("bad packed entry: %v", err)
+					return
+				}
+				entries = append(entries, e)
+			}
+		}
+		assert.Equal(t, int64(len(entries)), option["size"])
+
+		s.mu.Lock()
+		for _, e := range entries {
+			e := e.([]interface{})
+			s.events = append(s.events, fluentEvent{
+				Tag:    tag,
+				Time:   e[0],
+				Record: e[1].(map[string]interface{}),
+			})
+		}
+		s.mu.Unlock()
+
+		if chunk, ok := option["chunk"]; ok {
+			var ack bytes.Buffer
+			ack.Write([]byte{0x81, 0xa3, 'a', 'c', 'k', 0xa0 | byte(len(chunk.(string)))})
+			ack.WriteString(chunk.(string))
+			_, _ = conn.Write(ack.Bytes())
+		}
+	}
+}
+
+func (s *fluentServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var msgs []string
+	for _, e := range s.events {
+		msgs = append(msgs, fmt.Sprint(e.Record["msg"]))
+	}
+
+	return msgs
+}
+
+func newFluentLogger(t *testing.T, options *conlog.FluentOptions) (*conlog.Logger, *conlog.FluentSink) {
+	sink, err := conlog.NewFluentSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newHookLogger(sink), sink
+}
+
+func TestFluentSink_Modes(t *testing.T) {
+	for _, mode := range []conlog.FluentMode{conlog.FluentModeForward, conlog.FluentModePackedForward} {
+		for _, eventTime := range []bool{true, false} {
+			server := newFluentServer(t, "127.0.0.1:0", 0)
+			options := conlog.NewFluentOptions("tcp", server.Addr().String())
+			options.Tag = "app.test"
+			options.Mode = mode
+			options.RequireAck = true
+			options.DisableEventTime = !eventTime
+			log, sink := newFluentLogger(t, options)
+
+			before := time.Now()
+			log.Info("Info test")
+			log.WithFields(conlog.Fields{"user": "bob", "msg": "clash", "count": 3}).Warn("Warn test")
+			sink.Flush()
+
+			server.mu.Lock()
+			events := server.events
+			server.mu.Unlock()
+			t.Logf("mode = %d, eventTime = %t, events = %v", mode, eventTime, events)
+			if assert.Len(t, events, 2) {
+				assert.Equal(t, "app.test", events[0].Tag)
+				assert.Equal(t, map[string]interface{}{"level": "info", "msg": "Info test"}, events[0].Record)
+				assert.Equal(t, map[string]interface{}{
+					"level":      "warning",
+					"msg":        "Warn test",
+					"fields.msg": "clash",
+					"user":       "bob",
+					"count":      int64(3),
+				}, events[1].Record)
+				if eventTime {
+					assert.IsType(t, time.Time{}, events[0].Time)
+					assert.False(t, events[0].Time.(time.Time).Before(before.Truncate(time.Second)))
+				} else {
+					assert.Equal(t, before.Unix(), events[0].Time)
+				}
+			}
+			assert.NoError(t, sink.Close())
+			assert.Equal(t, uint64(0), sink.Dropped())
+			server.Close()
+		}
+	}
+}
+
+func TestFluentSink_Reconnect(t *testing.T) {
+	server := newFluentServer(t, "127.0.0.1:0", 2)
+	defer server.Close()
+	options := conlog.NewFluentOptions("tcp", server.Addr().String())
+	options.RequireAck = true
+	options.MinBackoff = time.Millisecond
+	log, sink := newFluentLogger(t, options)
+	defer sink.Close()
+
+	log.Error("Error test")
+	sink.Flush()
+	assert.Equal(t, []string{"Error test"}, server.messages())
+}
+
+func TestFluentSink_Buffering(t *testing.T) {
+	// Reserve an address and stop listening on it so the sink
+	// starts out disconnected.
+	server := newFluentServer(t, "127.0.0.1:0", 0)
+	addr := server.Addr().String()
+	server.Close()
+
+	options := conlog.NewFluentOptions("tcp", addr)
+	options.RequireAck = true
+	options.MaxRetries = 0
+	var errs []error
+	options.ErrorHandler = func(err error) {
+		errs = append(errs, err)
+	}
+	log, sink := newFluentLogger(t, options)
+	defer sink.Close()
+
+	log.Info("Buffered test")
+	sink.Flush()
+	assert.Len(t, errs, 1)
+
+	server = newFluentServer(t, addr, 0)
+	defer server.Close()
+	log.Info("Connected test")
+	sink.Flush()
+	assert.Equal(t, []string{"Buffered test", "Connected test"}, server.messages())
+	assert.Equal(t, uint64(0), sink.Dropped())
+}
+
+func TestFluentSink_Options(t *testing.T) {
+	_, err := conlog.NewFluentSink(nil)
+	assert.Error(t, err)
+	_, err = conlog.NewFluentSink(conlog.NewFluentOptions("udp", "localhost:24224"))
+	assert.Error(t, err)
+}
@@ -4,7 +4,11 @@
 
 package conlog
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
 
 const (
 	// Default time stamp format used when displaying wall clock
@@ -26,3 +30,41 @@ const (
 type Formatter interface {
 	Format(*Entry) ([]byte, error)
 }
+
+// needsQuoting reports whether a field value must be quoted to be
+// unambiguous when rendered as key=value.
+func needsQuoting(text string) bool {
+	if len(text) == 0 {
+		return true
+	}
+	for _, ch := range text {
+		if !((ch >= 'a' && ch <= 'z') ||
+			(ch >= 'A' && ch <= 'Z') ||
+			(ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '.' || ch == '_' || ch == '/' ||
+			ch == '@' || ch == '^' || ch == '+' || ch == ':') {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatFieldValue renders a field value for key=value output,
+// quoting and escaping it if necessary.
+func formatFieldValue(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case error:
+		text = v.Error()
+	default:
+		text = fmt.Sprint(v)
+	}
+	if needsQuoting(text) {
+		return strconv.Quote(text)
+	}
+
+	return text
+}
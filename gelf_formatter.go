@@ -0,0 +1,113 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GELFVersion is the GELF specification version written by
+// GELFFormatter.
+const GELFVersion = "1.1"
+
+// GELFFormatter formats entries as GELF 1.1 JSON messages for
+// Graylog-compatible collectors. The level is mapped to a syslog
+// severity and entry fields become additional fields, e.g., the field
+// "user" becomes "_user". Each message ends with a newline which
+// GELFWriter strips before sending.
+type GELFFormatter struct {
+	// Host is the name of the host sending the message. Defaults
+	// to os.Hostname().
+	Host string
+
+	// ShowPrintMessages controls whether output from the Print*()
+	// family of logging functions is written. It is dropped by
+	// default.
+	ShowPrintMessages bool
+}
+
+// NewGELFFormatter is the GELFFormatter constructor.
+func NewGELFFormatter() *GELFFormatter {
+	hostname, _ := os.Hostname()
+
+	return &GELFFormatter{
+		Host: hostname,
+	}
+}
+
+// Format renders a single log entry.
+func (f *GELFFormatter) Format(entry *Entry) ([]byte, error) {
+	if entry.Level == PrintLevel && !f.ShowPrintMessages {
+		return []byte{}, nil
+	}
+
+	data := make(Fields, len(entry.Fields)+8)
+	for k, v := range entry.Fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[gelfFieldName(k)] = v
+	}
+
+	msg := strings.TrimSuffix(entry.Message, "\n")
+	host := f.Host
+	if host == "" {
+		host = "-"
+	}
+	data["version"] = GELFVersion
+	data["host"] = host
+	data["short_message"] = msg
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		data["short_message"] = msg[:i]
+		data["full_message"] = msg
+	}
+	data["timestamp"] = float64(entry.Time.UnixNano()/int64(1e6)) / 1e3
+	data["level"] = syslogSeverity(entry.Level)
+	data["_level_name"] = entry.Level.String()
+	if entry.HasCaller() {
+		data["_func"] = entry.Caller.Function
+		data["_file"] = entry.Caller.File
+		data["_line"] = entry.Caller.Line
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to GELF, %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// gelfFieldName returns the additional field name for a field
+// key. Characters not allowed by GELF are replaced with
+// underscores. Keys that would clash with the reserved "_id" or the
+// fields written by GELFFormatter are prefixed with "fields.".
+func gelfFieldName(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, key)
+	switch key {
+	case "id", "level_name", "func", "file", "line":
+		key = "fields." + key
+	}
+
+	return "_" + key
+}
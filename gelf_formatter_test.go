@@ -0,0 +1,64 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGELFFormatter_Format(t *testing.T) {
+	formatter := conlog.NewGELFFormatter()
+	formatter.Host = "host"
+	entry := conlog.Entry{
+		Time:    time.Date(2019, 1, 2, 15, 4, 5, 250000000, time.UTC),
+		Level:   conlog.WarnLevel,
+		Message: "Warn test\nsecond line\n",
+		Fields:  conlog.Fields{"user": "bob", "id": 7, "bad key": true, "error": fmt.Errorf("boom")},
+	}
+
+	out, err := formatter.Format(&entry)
+	assert.NoError(t, err)
+	t.Logf("out string = %q", string(out))
+
+	var data map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out, &data))
+	var tests = map[string]interface{}{
+		"version":       "1.1",
+		"host":          "host",
+		"short_message": "Warn test",
+		"full_message":  "Warn test\nsecond line",
+		"timestamp":     1546441445.25,
+		"level":         float64(4),
+		"_level_name":   "warning",
+		"_user":         "bob",
+		"_fields.id":    float64(7),
+		"_bad_key":      true,
+		"_error":        "boom",
+	}
+	for key, value := range tests {
+		assert.Equal(t, value, data[key], key)
+	}
+	assert.Len(t, data, len(tests))
+}
+
+func TestGELFFormatter_PrintMessages(t *testing.T) {
+	formatter := conlog.NewGELFFormatter()
+	entry := conlog.Entry{Level: conlog.PrintLevel, Message: "Print test"}
+
+	out, err := formatter.Format(&entry)
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+
+	formatter.ShowPrintMessages = true
+	out, err = formatter.Format(&entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"short_message":"Print test"`)
+}
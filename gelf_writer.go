@@ -0,0 +1,230 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+)
+
+// GELFCompression selects the compression of GELF UDP messages.
+type GELFCompression int
+
+const (
+	// GELFCompressionUnknown is an unknown compression.
+	GELFCompressionUnknown GELFCompression = iota
+
+	// GELFCompressionNone sends messages uncompressed.
+	GELFCompressionNone
+
+	// GELFCompressionGzip compresses messages with gzip.
+	GELFCompressionGzip
+
+	// GELFCompressionZlib compresses messages with zlib.
+	GELFCompressionZlib
+)
+
+const (
+	// DefaultGELFChunkSize is the default maximum size of a GELF
+	// UDP datagram. It fits in the MTU of most networks.
+	DefaultGELFChunkSize = 1420
+
+	// gelfChunkHeaderSize is the size of the magic bytes, message
+	// id, sequence number, and sequence count of a chunk.
+	gelfChunkHeaderSize = 12
+
+	// gelfMaxChunks is the maximum number of chunks in a message.
+	gelfMaxChunks = 128
+)
+
+// gelfChunkMagic starts every chunk of a chunked GELF message.
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFWriter is an io.WriteCloser that sends each write as one GELF
+// message. It is meant to be used with GELFFormatter, e.g.,
+//
+//	w, err := conlog.NewGELFWriter("udp", "graylog:12201")
+//	log.SetOutput(w)
+//	log.SetErrorOutput(w)
+//	log.SetFormatter(conlog.NewGELFFormatter())
+//
+// UDP messages are optionally compressed and split into chunks if
+// they exceed ChunkSize. TCP messages are uncompressed and terminated
+// by a null byte. A trailing newline is stripped from each message.
+type GELFWriter struct {
+	// Compression is the compression used for UDP messages. The
+	// default is GELFCompressionNone.
+	Compression GELFCompression
+
+	// ChunkSize is the maximum size of a UDP datagram. The
+	// default is DefaultGELFChunkSize.
+	ChunkSize int
+
+	network string
+	addr    string
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+}
+
+// NewGELFWriter connects to a GELF collector. The network is "udp" or
+// "tcp" (or their 4/6 variants).
+func NewGELFWriter(network string, addr string) (*GELFWriter, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("Not a valid GELF network: %q", network)
+	}
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GELFWriter{
+		Compression: GELFCompressionNone,
+		ChunkSize:   DefaultGELFChunkSize,
+		network:     network,
+		addr:        addr,
+		conn:        conn,
+	}, nil
+}
+
+// Write sends p as a single GELF message. It returns os.ErrClosed
+// after Close.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte{'\n'})
+	if len(msg) == 0 {
+		return len(p), nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.conn == nil {
+		conn, err := net.Dial(w.network, w.addr)
+		if err != nil {
+			return 0, err
+		}
+		w.conn = conn
+	}
+	var err error
+	switch w.network {
+	case "tcp", "tcp4", "tcp6":
+		err = w.writeTCP(msg)
+	default:
+		err = w.writeUDP(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the connection to the collector.
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
+
+// writeTCP sends a null-terminated message. The connection is
+// dropped on error so the next write reconnects.
+func (w *GELFWriter) writeTCP(msg []byte) error {
+	frame := make([]byte, 0, len(msg)+1)
+	frame = append(frame, msg...)
+	frame = append(frame, 0)
+	if _, err := w.conn.Write(frame); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (w *GELFWriter) writeUDP(msg []byte) error {
+	msg, err := w.compress(msg)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := w.ChunkSize
+	if chunkSize <= gelfChunkHeaderSize {
+		chunkSize = DefaultGELFChunkSize
+	}
+	if len(msg) <= chunkSize {
+		_, err = w.conn.Write(msg)
+		return err
+	}
+
+	dataSize := chunkSize - gelfChunkHeaderSize
+	count := (len(msg) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF message of %d bytes needs %d chunks, the maximum is %d", len(msg), count, gelfMaxChunks)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 0, chunkSize)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * dataSize
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk = append(chunk[:0], gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, msg[seq*dataSize:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *GELFWriter) compress(msg []byte) ([]byte, error) {
+	var b bytes.Buffer
+	var err error
+	switch w.Compression {
+	case GELFCompressionGzip:
+		zw := gzip.NewWriter(&b)
+		if _, err = zw.Write(msg); err == nil {
+			err = zw.Close()
+		}
+	case GELFCompressionZlib:
+		zw := zlib.NewWriter(&b)
+		if _, err = zw.Write(msg); err == nil {
+			err = zw.Close()
+		}
+	default:
+		return msg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGELFLogger(t *testing.T, network string, addr string) (*conlog.Logger, *conlog.GELFWriter) {
+	w, err := conlog.NewGELFWriter(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := conlog.NewLogger()
+	log.SetOutput(w)
+	log.SetErrorOutput(w)
+	log.SetFormatter(conlog.NewGELFFormatter())
+
+	return log, w
+}
+
+// readGELFMessage reads one, possibly chunked, GELF message from conn
+// and decompresses it.
+func readGELFMessage(t *testing.T, conn net.PacketConn) map[string]interface{} {
+	var msg []byte
+	chunks := make(map[byte][]byte)
+	buf := make([]byte, 65536)
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		packet := buf[:n]
+		if !bytes.HasPrefix(packet, []byte{0x1e, 0x0f}) {
+			msg = append([]byte{}, packet...)
+			break
+		}
+		assert.True(t, n <= conlog.DefaultGELFChunkSize)
+		seq, count := packet[10], packet[11]
+		chunks[seq] = append([]byte{}, packet[12:]...)
+		if len(chunks) == int(count) {
+			for i := byte(0); i < count; i++ {
+				msg = append(msg, chunks[i]...)
+			}
+			break
+		}
+	}
+
+	var r io.Reader = bytes.NewReader(msg)
+	switch {
+	case bytes.HasPrefix(msg, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(r)
+		assert.NoError(t, err)
+		r = zr
+	case len(msg) > 0 && msg[0] == 0x78:
+		zr, err := zlib.NewReader(r)
+		assert.NoError(t, err)
+		r = zr
+	}
+	contents, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+
+	var data map[string]interface{}
+	assert.NoError(t, json.Unmarshal(contents, &data))
+
+	return data
+}
+
+func TestGELFWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	log, w := newGELFLogger(t, "udp", conn.LocalAddr().String())
+	defer w.Close()
+
+	var tests = []struct {
+		Compression conlog.GELFCompression
+		Message     string
+	}{
+		{conlog.GELFCompressionNone, "Small test"},
+		{conlog.GELFCompressionNone, strings.Repeat("Chunked test ", 500)},
+		{conlog.GELFCompressionGzip, "Gzip test"},
+		{conlog.GELFCompressionZlib, "Zlib test"},
+	}
+	for _, test := range tests {
+		w.Compression = test.Compression
+		log.Error(test.Message)
+		data := readGELFMessage(t, conn)
+		assert.Equal(t, test.Message, data["short_message"])
+		assert.Equal(t, float64(3), data["level"])
+	}
+
+	w.Compression = conlog.GELFCompressionNone
+	w.ChunkSize = 100
+	log.Info(strings.Repeat("x", 100*128))
+	log.Info("After too many chunks")
+	data := readGELFMessage(t, conn)
+	assert.Equal(t, "After too many chunks", data["short_message"])
+}
+
+func TestGELFWriter_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	msgs := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			msgs <- strings.TrimSuffix(msg, "\x00")
+		}
+	}()
+
+	log, w := newGELFLogger(t, "tcp", listener.Addr().String())
+	defer w.Close()
+	log.Info("Info test")
+	log.WithField("n", 1).Warn("Warn test")
+
+	for _, cmp := range []string{"Info test", "Warn test"} {
+		select {
+		case msg := <-msgs:
+			t.Logf("msg = %q", msg)
+			var data map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(msg), &data))
+			assert.Equal(t, cmp, data["short_message"])
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for GELF message")
+		}
+	}
+}
+
+func TestGELFWriter_WriteAfterClose(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := conlog.NewGELFWriter("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Close())
+	n, err := w.Write([]byte("Info test\n"))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, os.ErrClosed, err)
+	assert.NoError(t, w.Close())
+}
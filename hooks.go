@@ -0,0 +1,106 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"fmt"
+	"os"
+)
+
+// A Hook is fired for every entry logged at one of the levels
+// returned by Levels. Hooks are used for side effects such as
+// counting errors or forwarding entries to another service.
+type Hook interface {
+	// Levels returns the levels the hook is fired for.
+	Levels() []Level
+
+	// Fire is called with the entry before it is written. The
+	// entry must not be modified or retained after Fire returns.
+	Fire(*Entry) error
+}
+
+// LevelHooks maps a level to the hooks fired at that level.
+type LevelHooks map[Level][]Hook
+
+// Add adds hook to the levels it is fired for.
+func (hooks LevelHooks) Add(hook Hook) {
+	for _, level := range hook.Levels() {
+		hooks[level] = append(hooks[level], hook)
+	}
+}
+
+// Fire fires all the hooks for level. Levels added with
+// RegisterLevel that have no hooks of their own fire the hooks of
+// their severity. All hooks are fired even if one fails. The first
+// error is returned.
+func (hooks LevelHooks) Fire(level Level, entry *Entry) error {
+	levelHooks, ok := hooks[level]
+	if !ok {
+		levelHooks = hooks[level.severity()]
+	}
+	var firstErr error
+	for _, hook := range levelHooks {
+		if err := hook.Fire(entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// HookErrorPolicy is used to set what happens to an entry when one of
+// its hooks returns an error.
+type HookErrorPolicy uint32
+
+const (
+	// HookErrorPolicyUnknown is used for defensive
+	// programming. You should never see this.
+	HookErrorPolicyUnknown = iota
+
+	// HookErrorPolicyReport writes the hook error to stderr and
+	// then writes the entry. This is the default.
+	HookErrorPolicyReport
+
+	// HookErrorPolicyIgnore silently ignores the hook error and
+	// writes the entry.
+	HookErrorPolicyIgnore
+
+	// HookErrorPolicyDrop writes the hook error to stderr and
+	// does not write the entry.
+	HookErrorPolicyDrop
+)
+
+// fireHooks fires the logger hooks for the entry. It returns false if
+// the entry should not be written.
+func (entry *Entry) fireHooks() bool {
+	// The hooks map is replaced, never modified, by AddHook and
+	// RemoveHooks so it is fired without holding the lock.
+	entry.Log.mu.Lock()
+	hooks := entry.Log.hooks
+	policy := entry.Log.hookErrorPolicy
+	entry.Log.mu.Unlock()
+	if len(hooks) == 0 {
+		return true
+	}
+
+	err := hooks.Fire(entry.Level, entry)
+	if err == nil {
+		return true
+	}
+	switch policy {
+	case HookErrorPolicyIgnore:
+		return true
+	case HookErrorPolicyDrop:
+		entry.Log.mu.Lock()
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to fire hook, %v\n", err)
+		entry.Log.mu.Unlock()
+		return false
+	default:
+		entry.Log.mu.Lock()
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to fire hook, %v\n", err)
+		entry.Log.mu.Unlock()
+		return true
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+type testHook struct {
+	levels   []conlog.Level
+	err      error
+	messages []string
+	fields   []conlog.Fields
+}
+
+func (hook *testHook) Levels() []conlog.Level {
+	return hook.levels
+}
+
+func (hook *testHook) Fire(entry *conlog.Entry) error {
+	hook.messages = append(hook.messages, entry.Level.String()+": "+entry.Message)
+	hook.fields = append(hook.fields, entry.Fields)
+	return hook.err
+}
+
+// newHookLogger returns a logger that discards its output and fires
+// hook, e.g., one of the sinks.
+func newHookLogger(hook conlog.Hook) *conlog.Logger {
+	log := conlog.NewLogger()
+	log.SetOutput(ioutil.Discard)
+	log.SetErrorOutput(ioutil.Discard)
+	log.AddHook(hook)
+
+	return log
+}
+
+func TestHooks_Fire(t *testing.T) {
+	logger, out, errOut := newSimpleLogger(conlog.DebugLevel)
+	hook := &testHook{levels: []conlog.Level{conlog.ErrorLevel, conlog.WarnLevel}}
+	logger.AddHook(hook)
+
+	logger.Info("Not hooked")
+	logger.Warn("Hooked warning")
+	logger.WithField("key", "value").Errorf("Hooked %s", "error")
+	t.Logf("messages = %q", hook.messages)
+	assert.Equal(t, []string{"warning: Hooked warning\n", "error: Hooked error\n"}, hook.messages)
+	assert.Equal(t, conlog.Fields{"key": "value"}, hook.fields[1])
+	assert.Equal(t, "INFO Not hooked\nWARN Hooked warning\n", out.String())
+	assert.Equal(t, "ERRO Hooked error key=value\n", errOut.String())
+	out.Reset()
+	errOut.Reset()
+
+	removed := logger.RemoveHooks()
+	assert.Len(t, removed[conlog.WarnLevel], 1)
+	logger.Warn("Hooks removed")
+	assert.Len(t, hook.messages, 2)
+	assert.Equal(t, "WARN Hooks removed\n", out.String())
+}
+
+func TestHooks_CustomLevel(t *testing.T) {
+	logger, _, _ := newSimpleLogger(conlog.DebugLevel)
+	warnHook := &testHook{levels: []conlog.Level{conlog.WarnLevel}}
+	auditHook := &testHook{levels: []conlog.Level{auditLevel}}
+	logger.AddHook(warnHook)
+
+	// A custom level fires the hooks of its severity unless it
+	// has hooks of its own.
+	logger.Log(auditLevel, "Audit test")
+	logger.AddHook(auditHook)
+	logger.Log(auditLevel, "Audit hook test")
+	logger.Warn("Warn test")
+	assert.Equal(t, []string{"audit: Audit test\n", "warning: Warn test\n"}, warnHook.messages)
+	assert.Equal(t, []string{"audit: Audit hook test\n"}, auditHook.messages)
+}
+
+func TestHooks_ErrorPolicy(t *testing.T) {
+	logger, out, _ := newSimpleLogger(conlog.DebugLevel)
+	hook := &testHook{
+		levels: []conlog.Level{conlog.InfoLevel},
+		err:    fmt.Errorf("hook failed"),
+	}
+	logger.AddHook(hook)
+	assert.Equal(t, conlog.HookErrorPolicy(conlog.HookErrorPolicyReport), logger.GetHookErrorPolicy())
+
+	logger.SetHookErrorPolicy(conlog.HookErrorPolicyIgnore)
+	logger.Info("Written despite hook error")
+	assert.Equal(t, "INFO Written despite hook error\n", out.String())
+	out.Reset()
+
+	logger.SetHookErrorPolicy(conlog.HookErrorPolicyDrop)
+	logger.Info("Dropped because of hook error")
+	assert.Empty(t, out.String())
+	assert.Len(t, hook.messages, 2)
+}
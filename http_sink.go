@@ -0,0 +1,399 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// HTTPBatchFormat selects how the formatted entries of a batch are
+// combined into a request body.
+type HTTPBatchFormat int
+
+const (
+	// HTTPBatchFormatUnknown is an unknown format.
+	HTTPBatchFormatUnknown HTTPBatchFormat = iota
+
+	// HTTPBatchFormatLines sends one entry per line. With a
+	// JSONFormatter this is newline-delimited JSON.
+	HTTPBatchFormatLines
+
+	// HTTPBatchFormatJSONArray sends the entries as a JSON array.
+	// The formatter must produce JSON, e.g., JSONFormatter.
+	HTTPBatchFormatJSONArray
+)
+
+// DefaultHTTPMaxBatchBytes is the default largest request body sent
+// by an HTTPSink.
+const DefaultHTTPMaxBatchBytes = 1 << 20
+
+// HTTPSinkOptions configure an HTTPSink.
+type HTTPSinkOptions struct {
+	// URL is the endpoint batches are POSTed to.
+	URL string
+
+	// Formatter renders each entry. The default is a
+	// JSONFormatter.
+	Formatter Formatter
+
+	// BatchFormat combines the entries of a batch. The default is
+	// HTTPBatchFormatJSONArray.
+	BatchFormat HTTPBatchFormat
+
+	// ContentType is the request Content-Type. The default is
+	// "application/json" for HTTPBatchFormatJSONArray and
+	// "application/x-ndjson" for HTTPBatchFormatLines.
+	ContentType string
+
+	// Headers are added to each request.
+	Headers map[string]string
+
+	// Username and Password, if set, are sent using basic
+	// authentication.
+	Username string
+	Password string
+
+	// BearerToken, if set, is sent in the Authorization header.
+	BearerToken string
+
+	// Levels are the levels sent. The default is every level
+	// returned by GetAllLevels.
+	Levels []Level
+
+	// BatchSize, MaxBatchBytes, BatchInterval, and QueueSize
+	// control batching. A batch is sent when it holds BatchSize
+	// entries or MaxBatchBytes bytes of formatted entries, or
+	// BatchInterval after the last batch. Entries arriving when
+	// the queue is full are dropped and counted. The defaults are
+	// DefaultBatchSize, DefaultHTTPMaxBatchBytes,
+	// DefaultBatchInterval, and DefaultQueueSize.
+	BatchSize     int
+	MaxBatchBytes int
+	BatchInterval time.Duration
+	QueueSize     int
+
+	// MaxRetries, MinBackoff, and MaxBackoff control retrying
+	// failed requests. The defaults are DefaultMaxRetries,
+	// DefaultMinBackoff, and DefaultMaxBackoff.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// DeadLetterFile, if set, is a file undeliverable batches are
+	// appended to. Its entries are resent when the next HTTPSink
+	// using the file is created.
+	DeadLetterFile string
+
+	// Client is the HTTP client used for requests. The default
+	// has a 10 second timeout.
+	Client *http.Client
+
+	// ErrorHandler is called when a batch cannot be sent. The
+	// default writes the error to stderr.
+	ErrorHandler func(err error)
+}
+
+// NewHTTPSinkOptions is the constructor for HTTPSinkOptions.
+func NewHTTPSinkOptions(url string) *HTTPSinkOptions {
+	return &HTTPSinkOptions{
+		URL:           url,
+		Formatter:     NewJSONFormatter(),
+		BatchFormat:   HTTPBatchFormatJSONArray,
+		BatchSize:     DefaultBatchSize,
+		MaxBatchBytes: DefaultHTTPMaxBatchBytes,
+		BatchInterval: DefaultBatchInterval,
+		QueueSize:     DefaultQueueSize,
+		MaxRetries:    DefaultMaxRetries,
+		MinBackoff:    DefaultMinBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HTTPSink is a Hook that formats entries with any Formatter and POSTs
+// them in batches to an HTTP endpoint, e.g., an internal service or a
+// webhook. Add it to a logger with AddHook:
+//
+//	options := conlog.NewHTTPSinkOptions("https://logs.example.com/ingest")
+//	options.BearerToken = token
+//	options.DeadLetterFile = "/var/lib/myapp/logs.dead"
+//	sink, err := conlog.NewHTTPSink(options)
+//	log.AddHook(sink)
+//	defer sink.Close()
+//
+// Failed requests are retried with jittered exponential backoff.
+// Batches that still cannot be delivered are appended to the dead
+// letter file and replayed in the background by the next HTTPSink
+// created with it. Until it is closed, the sink is flushed by
+// HandleExit after the dead letter file has been replayed.
+type HTTPSink struct {
+	options HTTPSinkOptions
+	header  http.Header
+	batcher *batcher
+	retrier retrier
+
+	deadLetterMu sync.Mutex
+	replaying    sync.WaitGroup
+}
+
+var _ Hook = (*HTTPSink)(nil)
+
+// NewHTTPSink is the constructor for HTTPSink. A nil options is an
+// error as the URL is required.
+func NewHTTPSink(options *HTTPSinkOptions) (*HTTPSink, error) {
+	if options == nil || options.URL == "" {
+		return nil, fmt.Errorf("HTTP sink URL is required")
+	}
+	s := &HTTPSink{
+		options: *options,
+		header:  make(http.Header),
+	}
+	if s.options.Formatter == nil {
+		s.options.Formatter = NewJSONFormatter()
+	}
+	if s.options.BatchFormat == HTTPBatchFormatUnknown {
+		s.options.BatchFormat = HTTPBatchFormatJSONArray
+	}
+	if s.options.BatchSize <= 0 {
+		s.options.BatchSize = DefaultBatchSize
+	}
+	if s.options.MaxBatchBytes <= 0 {
+		s.options.MaxBatchBytes = DefaultHTTPMaxBatchBytes
+	}
+	if s.options.Levels == nil {
+		s.options.Levels = GetAllLevels()
+	}
+	if s.options.Client == nil {
+		s.options.Client = http.DefaultClient
+	}
+
+	for k, v := range s.options.Headers {
+		s.header.Set(k, v)
+	}
+	contentType := s.options.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+		if s.options.BatchFormat == HTTPBatchFormatLines {
+			contentType = "application/x-ndjson"
+		}
+	}
+	s.header.Set("Content-Type", contentType)
+	switch {
+	case s.options.BearerToken != "":
+		s.header.Set("Authorization", "Bearer "+s.options.BearerToken)
+	case s.options.Username != "" || s.options.Password != "":
+		credentials := s.options.Username + ":" + s.options.Password
+		s.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+
+	s.retrier = newRetrier(s.options.MaxRetries, s.options.MinBackoff, s.options.MaxBackoff)
+	s.batcher = newSizedBatcher(s.options.QueueSize, s.options.BatchSize, s.options.MaxBatchBytes,
+		s.options.BatchInterval, s.push)
+	if s.options.DeadLetterFile != "" {
+		s.replaying.Add(1)
+		go s.replay()
+	}
+	registerHook(s)
+
+	return s, nil
+}
+
+// Levels returns the levels the sink is fired for.
+func (s *HTTPSink) Levels() []Level {
+	return s.options.Levels
+}
+
+// Fire formats the entry with the Formatter and queues the line for
+// the next POST. A slow endpoint does not stall logging: lines beyond
+// QueueSize are dropped and counted by Dropped.
+func (s *HTTPSink) Fire(entry *Entry) error {
+	e := *entry
+	e.Buffer = nil
+	line, err := s.options.Formatter.Format(&e)
+	if err != nil {
+		return err
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) == 0 {
+		return nil
+	}
+	s.batcher.add(append([]byte(nil), line...))
+
+	return nil
+}
+
+// Flush waits for the dead letter file to be replayed, then sends all
+// queued entries and waits for the send to finish.
+func (s *HTTPSink) Flush() {
+	s.replaying.Wait()
+	s.batcher.flush()
+}
+
+// Close replays the dead letter file, sends all queued entries, and
+// stops the sink. Entries fired after Close are dropped.
+func (s *HTTPSink) Close() error {
+	unregisterHook(s)
+	s.replaying.Wait()
+	s.batcher.close()
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was
+// full or the sink was closed. Entries written to the dead letter file
+// are not dropped.
+func (s *HTTPSink) Dropped() uint64 {
+	return s.batcher.droppedCount()
+}
+
+// push sends a batch. It is called by the batcher.
+func (s *HTTPSink) push(batch []interface{}) {
+	entries := make([][]byte, len(batch))
+	for i, item := range batch {
+		entries[i] = item.([]byte)
+	}
+	s.deliver(entries)
+}
+
+// deliver sends entries, writing them to the dead letter file if they
+// cannot be sent.
+func (s *HTTPSink) deliver(entries [][]byte) {
+	body := s.encode(entries)
+	err := s.retrier.do(func() error {
+		return postBatch(s.options.Client, s.options.URL, s.header, body)
+	})
+	if err == nil {
+		return
+	}
+
+	err = fmt.Errorf("failed to send %d entries to %s, %v", len(entries), s.options.URL, err)
+	if s.options.DeadLetterFile != "" {
+		if dlErr := s.writeDeadLetters(entries); dlErr != nil {
+			err = fmt.Errorf("%v; failed to write dead letter file, %v", err, dlErr)
+		}
+	}
+	handleError(s.options.ErrorHandler, err)
+}
+
+// encode combines entries into a request body.
+func (s *HTTPSink) encode(entries [][]byte) []byte {
+	if s.options.BatchFormat == HTTPBatchFormatLines {
+		body := bytes.Join(entries, []byte("\n"))
+		return append(body, '\n')
+	}
+
+	body := []byte{'['}
+	body = append(body, bytes.Join(entries, []byte(","))...)
+
+	return append(body, ']')
+}
+
+// writeDeadLetters appends entries to the dead letter file, one JSON
+// string per line so entries containing newlines survive.
+func (s *HTTPSink) writeDeadLetters(entries [][]byte) error {
+	var b bytes.Buffer
+	for _, e := range entries {
+		quoted, err := json.Marshal(string(e))
+		if err != nil {
+			return err
+		}
+		b.Write(quoted)
+		b.WriteByte('\n')
+	}
+
+	s.deadLetterMu.Lock()
+	defer s.deadLetterMu.Unlock()
+	file, err := os.OpenFile(s.options.DeadLetterFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(b.Bytes()); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// replay resends the entries in the dead letter file. The file is
+// first renamed so entries that fail again are written to a new dead
+// letter file; a renamed file left by an interrupted replay is sent
+// first.
+func (s *HTTPSink) replay() {
+	defer s.replaying.Done()
+
+	replayName := s.options.DeadLetterFile + ".replay"
+	for _, name := range []string{replayName, s.options.DeadLetterFile} {
+		if name != replayName {
+			s.deadLetterMu.Lock()
+			err := os.Rename(name, replayName)
+			s.deadLetterMu.Unlock()
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				handleError(s.options.ErrorHandler, fmt.Errorf("failed to replay dead letter file, %v", err))
+				return
+			}
+		}
+		entries, err := readDeadLetters(replayName)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			handleError(s.options.ErrorHandler, fmt.Errorf("failed to replay dead letter file, %v", err))
+			return
+		}
+		for len(entries) > 0 {
+			n, size := 0, 0
+			for n < len(entries) && n < s.options.BatchSize &&
+				(n == 0 || size+len(entries[n]) <= s.options.MaxBatchBytes) {
+				size += len(entries[n])
+				n++
+			}
+			s.deliver(entries[:n])
+			entries = entries[n:]
+		}
+		if err := os.Remove(replayName); err != nil {
+			handleError(s.options.ErrorHandler, fmt.Errorf("failed to remove dead letter file, %v", err))
+		}
+	}
+}
+
+// readDeadLetters reads the entries written by writeDeadLetters.
+func readDeadLetters(name string) ([][]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries [][]byte
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e string
+			if err := json.Unmarshal(line, &e); err != nil {
+				return nil, fmt.Errorf("bad dead letter entry, %v", err)
+			}
+			entries = append(entries, []byte(e))
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
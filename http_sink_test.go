@@ -0,0 +1,176 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// httpSinkServer records the request bodies sent to it. It fails
+// requests while failing is set.
+type httpSinkServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	bodies  []string
+	headers http.Header
+	failing int32
+}
+
+func newHTTPSinkServer() *httpSinkServer {
+	s := &httpSinkServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.failing) != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		s.headers = r.Header
+		s.mu.Unlock()
+	}))
+
+	return s
+}
+
+func (s *httpSinkServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.bodies...)
+}
+
+func newHTTPSinkLogger(t *testing.T, options *conlog.HTTPSinkOptions) (*conlog.Logger, *conlog.HTTPSink) {
+	sink, err := conlog.NewHTTPSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newHookLogger(sink), sink
+}
+
+func newHTTPSinkFormatter() conlog.Formatter {
+	formatter := conlog.NewLogfmtFormatter()
+	formatter.TimestampType = conlog.TimestampTypeNone
+
+	return formatter
+}
+
+func TestHTTPSink_JSONArray(t *testing.T) {
+	server := newHTTPSinkServer()
+	defer server.Close()
+	options := conlog.NewHTTPSinkOptions(server.URL)
+	options.Formatter = conlog.NewJSONFormatter()
+	options.Formatter.(*conlog.JSONFormatter).DisableTimestamp = true
+	options.BatchSize = 2
+	options.Username = "user"
+	options.Password = "secret"
+	options.Headers = map[string]string{"X-Source": "test"}
+	log, sink := newHTTPSinkLogger(t, options)
+	defer sink.Close()
+
+	log.Info("Info test")
+	log.WithField("user", "bob").Warn("Warn test")
+	log.Error("Error test")
+	sink.Flush()
+
+	cmp := []string{
+		`[{"level":"info","msg":"Info test"},{"level":"warning","msg":"Warn test","user":"bob"}]`,
+		`[{"level":"error","msg":"Error test"}]`,
+	}
+	t.Logf("out = %q", server.requests())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, server.requests())
+	user, password, ok := (&http.Request{Header: server.headers}).BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "secret", password)
+	assert.Equal(t, "test", server.headers.Get("X-Source"))
+	assert.Equal(t, "application/json", server.headers.Get("Content-Type"))
+}
+
+func TestHTTPSink_Lines(t *testing.T) {
+	server := newHTTPSinkServer()
+	defer server.Close()
+	options := conlog.NewHTTPSinkOptions(server.URL)
+	options.Formatter = newHTTPSinkFormatter()
+	options.BatchFormat = conlog.HTTPBatchFormatLines
+	options.MaxBatchBytes = 60
+	options.BearerToken = "token"
+	log, sink := newHTTPSinkLogger(t, options)
+	defer sink.Close()
+
+	log.Info("First test")
+	log.Info("Second test")
+	log.Info("Third test")
+	sink.Flush()
+
+	cmp := []string{
+		"level=info msg=\"First test\"\nlevel=info msg=\"Second test\"\n",
+		"level=info msg=\"Third test\"\n",
+	}
+	t.Logf("out = %q", server.requests())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, server.requests())
+	assert.Equal(t, "Bearer token", server.headers.Get("Authorization"))
+	assert.Equal(t, "application/x-ndjson", server.headers.Get("Content-Type"))
+}
+
+func TestHTTPSink_DeadLetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conlog-http-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	deadLetterFile := filepath.Join(dir, "logs.dead")
+
+	server := newHTTPSinkServer()
+	defer server.Close()
+	atomic.StoreInt32(&server.failing, 1)
+	options := conlog.NewHTTPSinkOptions(server.URL)
+	options.Formatter = newHTTPSinkFormatter()
+	options.BatchFormat = conlog.HTTPBatchFormatLines
+	options.MaxRetries = 1
+	options.MinBackoff = time.Millisecond
+	options.DeadLetterFile = deadLetterFile
+	var errs []error
+	options.ErrorHandler = func(err error) {
+		errs = append(errs, err)
+	}
+	log, sink := newHTTPSinkLogger(t, options)
+
+	log.Info("Dead test")
+	log.Error("Multi-line\ntest")
+	assert.NoError(t, sink.Close())
+	assert.Len(t, errs, 1)
+	assert.Empty(t, server.requests())
+	_, err = os.Stat(deadLetterFile)
+	assert.NoError(t, err)
+
+	// The next sink replays the dead letter file.
+	atomic.StoreInt32(&server.failing, 0)
+	_, sink = newHTTPSinkLogger(t, options)
+	sink.Flush()
+	cmp := []string{"level=info msg=\"Dead test\"\nlevel=error msg=\"Multi-line\\ntest\"\n"}
+	t.Logf("out = %q", server.requests())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, server.requests())
+	assert.NoError(t, sink.Close())
+	_, err = os.Stat(deadLetterFile)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(deadLetterFile + ".replay")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultJournalSocket is the path of the systemd-journald native
+// protocol socket.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// maxJournalFieldName is the longest field name journald accepts.
+const maxJournalFieldName = 64
+
+// journalReservedFields are the field names written by
+// JournalFormatter itself. Entry fields with these names are prefixed
+// with "FIELDS_".
+var journalReservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// JournalFormatter formats entries in the systemd journal native
+// protocol for use with JournalWriter. The level is mapped to
+// PRIORITY, the message to MESSAGE, and entry fields become journal
+// fields with upper-cased names, e.g., the field "user_id" becomes
+// USER_ID. The caller, if reported, is written to CODE_FILE,
+// CODE_LINE, and CODE_FUNC.
+type JournalFormatter struct {
+	// SyslogIdentifier is written to SYSLOG_IDENTIFIER. Defaults
+	// to the program name.
+	SyslogIdentifier string
+
+	// ShowPrintMessages controls whether output from the Print*()
+	// family of logging functions is written. It is dropped by
+	// default.
+	ShowPrintMessages bool
+}
+
+// NewJournalFormatter is the JournalFormatter constructor.
+func NewJournalFormatter() *JournalFormatter {
+	return &JournalFormatter{
+		SyslogIdentifier: filepath.Base(os.Args[0]),
+	}
+}
+
+// Format renders a single log entry.
+func (f *JournalFormatter) Format(entry *Entry) ([]byte, error) {
+	if entry.Level == PrintLevel && !f.ShowPrintMessages {
+		return []byte{}, nil
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	appendJournalField(b, "PRIORITY", strconv.Itoa(syslogSeverity(entry.Level)))
+	if f.SyslogIdentifier != "" {
+		appendJournalField(b, "SYSLOG_IDENTIFIER", f.SyslogIdentifier)
+	}
+	appendJournalField(b, "MESSAGE", strings.TrimSuffix(entry.Message, "\n"))
+	if entry.HasCaller() {
+		appendJournalField(b, "CODE_FILE", entry.Caller.File)
+		appendJournalField(b, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		appendJournalField(b, "CODE_FUNC", entry.Caller.Function)
+	}
+	for _, key := range entry.Fields.sortedKeys() {
+		var value string
+		switch v := entry.Fields[key].(type) {
+		case string:
+			value = v
+		case error:
+			value = v.Error()
+		default:
+			value = fmt.Sprint(v)
+		}
+		appendJournalField(b, journalFieldName(key), value)
+	}
+
+	return b.Bytes(), nil
+}
+
+// appendJournalField writes a field in the native protocol. Values
+// containing a newline use the binary form: the name, a newline, the
+// value length as a little-endian uint64, and the value.
+func appendJournalField(b *bytes.Buffer, name string, value string) {
+	b.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b.WriteByte('\n')
+	b.Write(size[:])
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName converts a field key into a valid journal field
+// name: upper-case letters, digits, and underscores, not starting
+// with an underscore or digit, and at most 64 characters.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || journalReservedFields[name] {
+		name = "FIELDS_" + name
+	}
+	if len(name) > maxJournalFieldName {
+		name = name[:maxJournalFieldName]
+	}
+
+	return name
+}
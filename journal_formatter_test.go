@@ -0,0 +1,49 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalFormatter_Format(t *testing.T) {
+	formatter := conlog.NewJournalFormatter()
+	formatter.SyslogIdentifier = "app"
+
+	var tests = []struct {
+		Entry  conlog.Entry
+		CmpStr string
+	}{
+		{
+			conlog.Entry{Level: conlog.ErrorLevel, Message: "Error test\n"},
+			"PRIORITY=3\nSYSLOG_IDENTIFIER=app\nMESSAGE=Error test\n",
+		},
+		{
+			conlog.Entry{Level: conlog.DebugLevel, Message: "Debug test\n",
+				Fields: conlog.Fields{"user-id": 7, "message": "clash", "_hidden": "x", "2fa": true}},
+			"PRIORITY=7\nSYSLOG_IDENTIFIER=app\nMESSAGE=Debug test\n" +
+				"FIELDS_2FA=true\nHIDDEN=x\nFIELDS_MESSAGE=clash\nUSER_ID=7\n",
+		},
+		{
+			conlog.Entry{Level: conlog.WarnLevel, Message: "two\nlines\n"},
+			"PRIORITY=4\nSYSLOG_IDENTIFIER=app\nMESSAGE\n\x09\x00\x00\x00\x00\x00\x00\x00two\nlines\n",
+		},
+		{
+			conlog.Entry{Level: conlog.PrintLevel, Message: "Print test"},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		out, err := formatter.Format(&test.Entry)
+		assert.NoError(t, err)
+		t.Logf("out string = %q", string(out))
+		t.Logf("cmp string = %q", test.CmpStr)
+		assert.Equal(t, test.CmpStr, string(out))
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package conlog
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// JournalWriter is an io.WriteCloser that sends each write as one
+// entry to systemd-journald using the native protocol. It is meant to
+// be used with JournalFormatter, e.g.,
+//
+//	w, err := conlog.NewJournalWriter("")
+//	log.SetOutput(w)
+//	log.SetErrorOutput(w)
+//	log.SetFormatter(conlog.NewJournalFormatter())
+//
+// Entries too large for a datagram are written to a sealed memfd, or
+// an unlinked temporary file if memfd is not available, whose
+// descriptor is passed to journald instead.
+type JournalWriter struct {
+	path string
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewJournalWriter connects to the journald socket at path. An empty
+// path uses DefaultJournalSocket.
+func NewJournalWriter(path string) (*JournalWriter, error) {
+	if path == "" {
+		path = DefaultJournalSocket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournalWriter{
+		path: path,
+		conn: conn,
+	}, nil
+}
+
+// Write sends p as a single journal entry.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return 0, os.ErrClosed
+	}
+	_, err := w.conn.Write(p)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		err = w.writeFile(p)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the connection to journald.
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
+
+// writeFile writes p to a memfd or temporary file and passes its
+// descriptor to journald.
+func (w *JournalWriter) writeFile(p []byte) error {
+	file, err := journalMemfd()
+	if err != nil {
+		file, err = journalTempFile()
+		if err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+
+	if _, err := file.Write(p); err != nil {
+		return err
+	}
+	// Sealing fails on temporary files which is harmless.
+	_, _ = unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS,
+		unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL)
+	raw, err := w.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(file.Fd()))
+	var sendErr error
+	err = raw.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+
+	return sendErr
+}
+
+func journalMemfd() (*os.File, error) {
+	fd, err := unix.MemfdCreate("conlog-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(fd), "conlog-journal"), nil
+}
+
+func journalTempFile() (*os.File, error) {
+	file, err := ioutil.TempFile("/dev/shm", "conlog-journal-")
+	if err != nil {
+		file, err = ioutil.TempFile("", "conlog-journal-")
+		if err != nil {
+			return nil, err
+		}
+	}
+	os.Remove(file.Name())
+
+	return file, nil
+}
+
+// IsJournalStream reports whether stdout or stderr is connected to
+// the journal, i.e., the process was started by systemd with its
+// output going to journald. Programs can use it to switch to
+// JournalWriter or to drop timestamps that journald adds itself.
+func IsJournalStream() bool {
+	stream := os.Getenv("JOURNAL_STREAM")
+	if stream == "" {
+		return false
+	}
+	for _, file := range []*os.File{os.Stdout, os.Stderr} {
+		var stat syscall.Stat_t
+		if err := syscall.Fstat(int(file.Fd()), &stat); err != nil {
+			continue
+		}
+		if stream == fmt.Sprintf("%d:%d", stat.Dev, stat.Ino) {
+			return true
+		}
+	}
+
+	return false
+}
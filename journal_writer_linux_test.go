@@ -0,0 +1,104 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package conlog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// readJournalEntry reads one datagram from conn. If it carries a file
+// descriptor the entry is read from the file instead.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	if oobn == 0 {
+		return string(buf[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	assert.NoError(t, err)
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	assert.NoError(t, err)
+	file := os.NewFile(uintptr(fds[0]), "journal")
+	defer file.Close()
+	file.Seek(0, 0)
+	contents, err := ioutil.ReadAll(file)
+	assert.NoError(t, err)
+
+	return string(contents)
+}
+
+func TestJournalWriter(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := conlog.NewJournalWriter(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer w.Close()
+	formatter := conlog.NewJournalFormatter()
+	formatter.SyslogIdentifier = "app"
+	log := conlog.NewLogger()
+	log.SetOutput(w)
+	log.SetErrorOutput(w)
+	log.SetFormatter(formatter)
+
+	log.WithField("user", "bob").Info("Info test")
+	entry := readJournalEntry(t, conn)
+	cmpStr := "PRIORITY=6\nSYSLOG_IDENTIFIER=app\nMESSAGE=Info test\nUSER=bob\n"
+	t.Logf("out string = %q", entry)
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, entry)
+
+	large := strings.Repeat("x", 1<<20)
+	log.Error(large)
+	entry = readJournalEntry(t, conn)
+	cmpStr = "PRIORITY=3\nSYSLOG_IDENTIFIER=app\nMESSAGE=" + large + "\n"
+	assert.Equal(t, len(cmpStr), len(entry))
+	assert.True(t, entry == cmpStr)
+}
+
+func TestIsJournalStream(t *testing.T) {
+	defer os.Setenv("JOURNAL_STREAM", os.Getenv("JOURNAL_STREAM"))
+
+	os.Unsetenv("JOURNAL_STREAM")
+	assert.False(t, conlog.IsJournalStream())
+
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(int(os.Stderr.Fd()), &stat); err != nil {
+		t.Skip(err)
+	}
+	os.Setenv("JOURNAL_STREAM", "0:0")
+	assert.False(t, conlog.IsJournalStream())
+	os.Setenv("JOURNAL_STREAM", fmt.Sprintf("%d:%d", stat.Dev, stat.Ino))
+	assert.True(t, conlog.IsJournalStream())
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package conlog
+
+import (
+	"errors"
+)
+
+// JournalWriter sends entries to systemd-journald. It is only
+// supported on Linux.
+type JournalWriter struct{}
+
+// NewJournalWriter always fails as journald is only available on
+// Linux.
+func NewJournalWriter(path string) (*JournalWriter, error) {
+	return nil, errors.New("journald is only supported on Linux")
+}
+
+// Write always fails.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("journald is only supported on Linux")
+}
+
+// Close does nothing.
+func (w *JournalWriter) Close() error {
+	return nil
+}
+
+// IsJournalStream always returns false as journald is only available
+// on Linux.
+func IsJournalStream() bool {
+	return false
+}
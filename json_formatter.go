@@ -0,0 +1,139 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type fieldKey string
+
+// FieldMap allows customization of the key names for the default
+// fields written by structured formatters such as JSONFormatter.
+type FieldMap map[fieldKey]string
+
+// Default key names for the default fields.
+const (
+	FieldKeyMsg   = "msg"
+	FieldKeyLevel = "level"
+	FieldKeyTime  = "time"
+	FieldKeyFunc  = "func"
+	FieldKeyFile  = "file"
+)
+
+func (f FieldMap) resolve(key fieldKey) string {
+	if k, ok := f[key]; ok {
+		return k
+	}
+
+	return string(key)
+}
+
+// JSONFormatter formats logs into JSON objects, one per line.
+type JSONFormatter struct {
+	// TimestampFormat is the time.Format() format used for the
+	// time field. Defaults to time.RFC3339.
+	TimestampFormat string
+
+	// DisableTimestamp suppresses the time field. Defaults to
+	// false.
+	DisableTimestamp bool
+
+	// ShowPrintMessages controls whether output from the Print*()
+	// family of logging functions is written. Print* output is
+	// usually meant for a human reading the console and is
+	// dropped by default.
+	ShowPrintMessages bool
+
+	// PrettyPrint indents the JSON output. Defaults to false.
+	PrettyPrint bool
+
+	// FieldMap allows users to customize the names of keys for
+	// default fields. As an example:
+	//
+	//  formatter := &JSONFormatter{
+	//      FieldMap: FieldMap{
+	//          FieldKeyTime:  "@timestamp",
+	//          FieldKeyLevel: "@level",
+	//          FieldKeyMsg:   "@message",
+	//      },
+	//  }
+	FieldMap FieldMap
+}
+
+// NewJSONFormatter is the JSONFormatter constructor.
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{
+		TimestampFormat: DefaultWallclockTimestampFormat,
+	}
+}
+
+// Format renders a single log entry.
+func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
+	if entry.Level == PrintLevel && !f.ShowPrintMessages {
+		return []byte{}, nil
+	}
+
+	data := make(Fields, len(entry.Fields)+3)
+	for k, v := range entry.Fields {
+		switch v := v.(type) {
+		case error:
+			// Otherwise errors are ignored by
+			// `encoding/json`.
+			data[k] = v.Error()
+		default:
+			data[k] = v
+		}
+	}
+	prefixFieldClashes(data, f.FieldMap)
+
+	if !f.DisableTimestamp {
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = DefaultWallclockTimestampFormat
+		}
+		data[f.FieldMap.resolve(FieldKeyTime)] = entry.Time.Format(timestampFormat)
+	}
+	data[f.FieldMap.resolve(FieldKeyMsg)] = strings.TrimSuffix(entry.Message, "\n")
+	data[f.FieldMap.resolve(FieldKeyLevel)] = entry.Level.String()
+	if entry.HasCaller() {
+		data[f.FieldMap.resolve(FieldKeyFunc)] = entry.Caller.Function
+		data[f.FieldMap.resolve(FieldKeyFile)] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	if f.PrettyPrint {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// prefixFieldClashes renames user fields that would otherwise
+// overwrite one of the default fields by prefixing them with
+// "fields.".
+func prefixFieldClashes(data Fields, fieldMap FieldMap) {
+	for _, key := range []fieldKey{FieldKeyTime, FieldKeyMsg, FieldKeyLevel, FieldKeyFunc, FieldKeyFile} {
+		k := fieldMap.resolve(key)
+		if v, ok := data[k]; ok {
+			data["fields."+k] = v
+			delete(data, k)
+		}
+	}
+}
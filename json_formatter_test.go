@@ -0,0 +1,79 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newJSONLogger(formatter *conlog.JSONFormatter) (*conlog.Logger, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	log := conlog.NewLogger()
+	log.SetOutput(out)
+	log.SetErrorOutput(out)
+	log.SetFormatter(formatter)
+	log.SetLevel(conlog.DebugLevel)
+
+	return log, out
+}
+
+func TestJSONFormatter_Format(t *testing.T) {
+	logger, out := newJSONLogger(conlog.NewJSONFormatter())
+
+	logger.WithFields(conlog.Fields{"count": 3, "msg": "clash"}).
+		WithError(fmt.Errorf("boom")).
+		Warn("JSON test")
+	t.Logf("out string = %q", out.String())
+
+	var data map[string]interface{}
+	err := json.Unmarshal(out.Bytes(), &data)
+	assert.NoError(t, err)
+	assert.Equal(t, "JSON test", data["msg"])
+	assert.Equal(t, "warning", data["level"])
+	assert.Equal(t, float64(3), data["count"])
+	assert.Equal(t, "boom", data["error"])
+	assert.Equal(t, "clash", data["fields.msg"])
+	assert.Contains(t, data, "time")
+	assert.True(t, strings.HasSuffix(out.String(), "}\n"))
+}
+
+func TestJSONFormatter_Options(t *testing.T) {
+	formatter := conlog.NewJSONFormatter()
+	formatter.DisableTimestamp = true
+	formatter.FieldMap = conlog.FieldMap{
+		conlog.FieldKeyMsg:   "message",
+		conlog.FieldKeyLevel: "severity",
+	}
+	logger, out := newJSONLogger(formatter)
+
+	logger.Info("Renamed keys")
+	cmpStr := `{"message":"Renamed keys","severity":"info"}` + "\n"
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	// Print* output is dropped by default.
+	logger.Print("Dropped")
+	assert.Empty(t, out.String())
+
+	formatter.ShowPrintMessages = true
+	logger.Print("Kept\n")
+	cmpStr = `{"message":"Kept","severity":"print"}` + "\n"
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	formatter.PrettyPrint = true
+	logger.Info("Pretty")
+	cmpStr = "{\n  \"message\": \"Pretty\",\n  \"severity\": \"info\"\n}\n"
+	assert.Equal(t, cmpStr, out.String())
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// firstCustomLevel is the value of the first level returned by
+// RegisterLevel. It leaves room for more built-in levels.
+const firstCustomLevel Level = 100
+
+// levelInfo describes how a level is named, filtered, and displayed.
+type levelInfo struct {
+	// Name returned by Level.String() and accepted by
+	// ParseLevel().
+	name string
+
+	// The built-in level this level is filtered and routed like.
+	severity Level
+
+	// ANSI color code used for the level when colors are
+	// enabled.
+	color int
+
+	// Label used with LogLevelFormatShort, e.g., "INFO".
+	shortName string
+}
+
+var (
+	levelsMu sync.RWMutex
+
+	// levels contains the built-in levels and the levels added
+	// with RegisterLevel.
+	levels = map[Level]*levelInfo{
+		PanicLevel: {"panic", PanicLevel, red, "PANI"},
+		FatalLevel: {"fatal", FatalLevel, red, "FATA"},
+		ErrorLevel: {"error", ErrorLevel, red, "ERRO"},
+		WarnLevel:  {"warning", WarnLevel, yellow, "WARN"},
+		InfoLevel:  {"info", InfoLevel, green, "INFO"},
+		DebugLevel: {"debug", DebugLevel, blue, "DEBU"},
+		TraceLevel: {"trace", TraceLevel, gray, "TRAC"},
+		PrintLevel: {"print", PrintLevel, green, "PRIN"},
+	}
+
+	nextCustomLevel = firstCustomLevel
+
+	// customLevels are the levels added with RegisterLevel in
+	// the order they were added.
+	customLevels []Level
+)
+
+// RegisterLevel adds a user-defined level. The level is named name
+// (e.g., "notice") and is filtered and routed like severity, which
+// must be one of the built-in levels. For example, a level registered
+// with a severity of InfoLevel is output whenever Info messages are.
+// The level is displayed using the ANSI color code color (e.g., 32 for
+// green) and shortName when LogLevelFormatShort is used.
+//
+// The returned Level can be used with Log, Logf, and Logln, parsed with
+// ParseLevel, and passed to SetLevel. Levels are usually registered
+// when a package is initialized:
+//
+//	var NoticeLevel = conlog.RegisterLevel("notice", conlog.InfoLevel, 36, "NOTI")
+//
+// RegisterLevel panics if name is already in use or severity is not a
+// built-in level.
+func RegisterLevel(name string, severity Level, color int, shortName string) Level {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	if severity > TraceLevel {
+		panic(fmt.Sprintf("conlog: invalid severity %d for level %q", severity, name))
+	}
+	for _, info := range levels {
+		if strings.EqualFold(info.name, name) {
+			panic(fmt.Sprintf("conlog: level %q already registered", name))
+		}
+	}
+
+	level := nextCustomLevel
+	nextCustomLevel++
+	levels[level] = &levelInfo{
+		name:      strings.ToLower(name),
+		severity:  severity,
+		color:     color,
+		shortName: shortName,
+	}
+	customLevels = append(customLevels, level)
+
+	return level
+}
+
+// GetAllLevels returns a copy of AllLevels followed by the levels
+// added with RegisterLevel.
+func GetAllLevels() []Level {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	all := make([]Level, 0, len(AllLevels)+len(customLevels))
+	all = append(all, AllLevels...)
+
+	return append(all, customLevels...)
+}
+
+// info returns the description of the level or nil if it is unknown.
+func (level Level) info() *levelInfo {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	return levels[level]
+}
+
+// severity returns the built-in level used when filtering and routing
+// the level.
+func (level Level) severity() Level {
+	if level <= PrintLevel {
+		return level
+	}
+	if info := level.info(); info != nil {
+		return info.severity
+	}
+
+	return level
+}
+
+// shortName returns the label used with LogLevelFormatShort.
+func (level Level) shortName() string {
+	if info := level.info(); info != nil {
+		return info.shortName
+	}
+
+	return "UNKN"
+}
+
+// color returns the ANSI color code used when displaying the level.
+func (level Level) color() int {
+	if info := level.info(); info != nil {
+		return info.color
+	}
+
+	return green
+}
+
+// lookupLevel returns the level named name ignoring case.
+func lookupLevel(name string) (Level, bool) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	for level, info := range levels {
+		if level != PrintLevel && strings.EqualFold(info.name, name) {
+			return level, true
+		}
+	}
+
+	return 0, false
+}
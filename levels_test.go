@@ -0,0 +1,118 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditLevel = conlog.RegisterLevel("audit", conlog.WarnLevel, 35, "AUDT")
+
+func TestLevels_ParseLevel(t *testing.T) {
+	var tests = []struct {
+		Name  string
+		Level conlog.Level
+	}{
+		{"panic", conlog.PanicLevel},
+		{"FATAL", conlog.FatalLevel},
+		{"error", conlog.ErrorLevel},
+		{"warn", conlog.WarnLevel},
+		{"Warning", conlog.WarnLevel},
+		{"info", conlog.InfoLevel},
+		{"debug", conlog.DebugLevel},
+		{"trace", conlog.TraceLevel},
+		{"AUDIT", auditLevel},
+	}
+
+	for _, test := range tests {
+		level, err := conlog.ParseLevel(test.Name)
+		t.Logf("name = %q, level = %d", test.Name, level)
+		assert.NoError(t, err)
+		assert.Equal(t, test.Level, level)
+	}
+
+	_, err := conlog.ParseLevel("print")
+	assert.Error(t, err)
+	_, err = conlog.ParseLevel("nosuchlevel")
+	assert.Error(t, err)
+
+	assert.Equal(t, "audit", auditLevel.String())
+	assert.Contains(t, conlog.GetAllLevels(), auditLevel)
+	assert.NotContains(t, conlog.AllLevels, auditLevel)
+}
+
+func TestLevels_RegisterLevelDuplicate(t *testing.T) {
+	assert.Panics(t, func() { conlog.RegisterLevel("Audit", conlog.InfoLevel, 32, "AUDT") })
+	assert.Panics(t, func() { conlog.RegisterLevel("bogus", auditLevel, 32, "BOGU") })
+}
+
+func TestLevels_Log(t *testing.T) {
+	logger, out, errOut := newSimpleLogger(conlog.ErrorLevel)
+
+	// The custom level is filtered like its severity.
+	logger.Log(auditLevel, "Suppressed")
+	assert.Empty(t, out.String())
+	logger.SetLevel(conlog.WarnLevel)
+	logger.Log(auditLevel, "Audit", "ed")
+	logger.Logf(auditLevel, "Audit%s", "ed")
+	logger.Logln(auditLevel, "Audit", "ed")
+	cmpStr := "AUDT Audited\nAUDT Audited\nAUDT Audit ed\n"
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	// A logger can be set to a custom level.
+	logger.SetLevel(auditLevel)
+	logger.Info("Suppressed")
+	logger.Warn("Output")
+	assert.Equal(t, "WARN Output\n", out.String())
+	out.Reset()
+
+	// Log never panics or exits.
+	logger.Log(conlog.PanicLevel, "No panic")
+	logger.Log(conlog.FatalLevel, "No exit")
+	assert.Equal(t, "PANI No panic\nFATA No exit\n", errOut.String())
+}
+
+func TestLevels_Formatters(t *testing.T) {
+	logger, out, _ := newSimpleLogger(conlog.InfoLevel)
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.LogLevelFmt = conlog.LogLevelFormatLongTitle
+	logger.SetFormatter(formatter)
+	logger.Log(auditLevel, "Long title")
+	assert.Equal(t, "Audit Long title\n", out.String())
+	out.Reset()
+
+	template, err := conlog.NewTemplateFormatter("{{.Level | short | color}} {{.Message}}")
+	assert.NoError(t, err)
+	template.ForceColors = true
+	logger.SetFormatter(template)
+	logger.Log(auditLevel, "Colored")
+	assert.Equal(t, "\x1b[35mAUDT\x1b[0m Colored\n", out.String())
+}
+
+func TestLevels_LoggersLog(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	loggers := conlog.NewLoggers(plainLogger{loggerList[0]}, loggerList[1])
+
+	loggers.Log(auditLevel, "Audit", "ed")
+	loggers.Logf(conlog.WarnLevel, "Warn%s", "ed")
+	loggers.Logln(conlog.FatalLevel, "No", "exit")
+
+	// A logger without Log is logged to at the severity of the
+	// level. Fatal messages do not make it exit.
+	cmp := "WARN Audited\nWARN Warned\nFATA No exit\n"
+	t.Logf("out = %q", outs[0].String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, outs[0].String())
+	cmp = "AUDT Audited\nWARN Warned\nFATA No exit\n"
+	t.Logf("out = %q", outs[1].String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, outs[1].String())
+}
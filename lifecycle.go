@@ -0,0 +1,365 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultFlushTimeout is the default time HandleExit waits for the
+// loggers to be flushed.
+const DefaultFlushTimeout = 5 * time.Second
+
+// ErrFlushTimeout is returned by FlushLoggers when the registered
+// loggers are not flushed within the flush timeout.
+var ErrFlushTimeout = errors.New("timed out flushing loggers")
+
+// Syncer is implemented by outputs that can commit buffered writes to
+// stable storage, e.g., *os.File.
+type Syncer interface {
+	Sync() error
+}
+
+// Flusher is implemented by loggers and outputs that buffer entries,
+// e.g., *Logger, *Loggers, and *bufio.Writer.
+type Flusher interface {
+	Flush() error
+}
+
+// hookFlusher is implemented by hooks that deliver entries in the
+// background, e.g., *LokiSink.
+type hookFlusher interface {
+	Flush()
+}
+
+// flushedHook registers a hookFlusher with RegisterLogger. It is
+// comparable so the hook can be unregistered.
+type flushedHook struct {
+	hook hookFlusher
+}
+
+func (h flushedHook) Flush() error {
+	h.hook.Flush()
+	return nil
+}
+
+// registerHook registers a hook that delivers entries in the
+// background so it is flushed before the program exits. It is called
+// by the sinks when they are created.
+func registerHook(hook hookFlusher) {
+	RegisterLogger(flushedHook{hook})
+}
+
+// unregisterHook removes a hook registered with registerHook. It is
+// called by the sinks when they are closed.
+func unregisterHook(hook hookFlusher) {
+	UnregisterLogger(flushedHook{hook})
+}
+
+var (
+	flushLoggersMu sync.Mutex
+	flushLoggers   []Flusher
+	flushTimeout   = int64(DefaultFlushTimeout)
+
+	// exitLoggers are the loggers that logged a Fatal*() message
+	// since they were last flushed.
+	exitLoggers []Flusher
+)
+
+func init() {
+	RegisterLogger(std)
+}
+
+// RegisterLogger registers a logger, typically a *Logger or *Loggers,
+// that is flushed by FlushLoggers and by HandleExit before the
+// program exits. The standard logger, loggers
+// with asynchronous logging enabled, and the sinks, e.g., LokiSink,
+// until they are closed, are registered automatically. Registering a
+// logger more than once has no effect.
+func RegisterLogger(logger Flusher) {
+	flushLoggersMu.Lock()
+	defer flushLoggersMu.Unlock()
+
+	for _, l := range flushLoggers {
+		if sameValue(l, logger) {
+			return
+		}
+	}
+	flushLoggers = append(flushLoggers, logger)
+}
+
+// UnregisterLogger removes a logger registered with RegisterLogger.
+func UnregisterLogger(logger Flusher) {
+	flushLoggersMu.Lock()
+	defer flushLoggersMu.Unlock()
+
+	loggers := make([]Flusher, 0, len(flushLoggers))
+	for _, l := range flushLoggers {
+		if !sameValue(l, logger) {
+			loggers = append(loggers, l)
+		}
+	}
+	flushLoggers = loggers
+}
+
+// SetFlushTimeout sets how long FlushLoggers and HandleExit wait for
+// the loggers to be flushed. Zero or less waits indefinitely.
+func SetFlushTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&flushTimeout, int64(timeout))
+}
+
+// GetFlushTimeout returns the flush timeout.
+func GetFlushTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&flushTimeout))
+}
+
+// FlushLoggers flushes the registered loggers and the loggers that
+// have logged a Fatal*() message. It returns the first error or
+// ErrFlushTimeout if they are not flushed within the flush timeout.
+func FlushLoggers() error {
+	return flushRegistered(nil)
+}
+
+// markForExit records that logger logged a Fatal*() message so
+// HandleExit flushes it even if it is not registered.
+func markForExit(logger Flusher) {
+	flushLoggersMu.Lock()
+	defer flushLoggersMu.Unlock()
+
+	for _, l := range exitLoggers {
+		if sameValue(l, logger) {
+			return
+		}
+	}
+	exitLoggers = append(exitLoggers, logger)
+}
+
+// flushRegistered flushes the registered loggers and the loggers
+// marked with markForExit, and then runs after, if not nil, waiting at
+// most the flush timeout. A panicking Flush does not prevent the
+// others from running.
+func flushRegistered(after func()) error {
+	flushLoggersMu.Lock()
+	var loggers []Flusher
+	for _, l := range append(append([]Flusher(nil), flushLoggers...), exitLoggers...) {
+		duplicate := false
+		for _, seen := range loggers {
+			if sameValue(seen, l) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			loggers = append(loggers, l)
+		}
+	}
+	exitLoggers = nil
+	flushLoggersMu.Unlock()
+
+	errs := make(chan error, 1)
+	go func() {
+		var firstErr error
+		for _, l := range loggers {
+			func() {
+				defer func() {
+					_ = recover()
+				}()
+				if err := l.Flush(); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}()
+		}
+		if after != nil {
+			after()
+		}
+		errs <- firstErr
+	}()
+
+	timeout := GetFlushTimeout()
+	if timeout <= 0 {
+		return <-errs
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errs:
+		return err
+	case <-timer.C:
+		return ErrFlushTimeout
+	}
+}
+
+// flushOnExit flushes the loggers and runs the exit handlers before
+// the program exits, reporting errors on stderr.
+func flushOnExit() {
+	if err := flushRegistered(runExitHandlers); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+}
+
+// sameValue returns true if a and b are the same comparable value.
+func sameValue(a, b interface{}) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// isStdStream returns true if w is os.Stdout or os.Stderr, which are
+// neither synced nor closed.
+func isStdStream(w interface{}) bool {
+	f, ok := w.(*os.File)
+	return ok && (f == os.Stdout || f == os.Stderr)
+}
+
+// distinctValues returns values without duplicates, standard streams,
+// and nils.
+func distinctValues(values []interface{}) []interface{} {
+	var distinct []interface{}
+	for _, v := range values {
+		if v == nil || isStdStream(v) {
+			continue
+		}
+		seen := false
+		for _, d := range distinct {
+			if sameValue(d, v) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			distinct = append(distinct, v)
+		}
+	}
+
+	return distinct
+}
+
+// syncAll flushes each distinct Flusher and syncs each distinct
+// Syncer in values. The first error is returned.
+func syncAll(values []interface{}) error {
+	var firstErr error
+	for _, v := range distinctValues(values) {
+		if f, ok := v.(Flusher); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if s, ok := v.(Syncer); ok {
+			// Pipes and terminals cannot be synced.
+			if err := s.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// closeAll closes each distinct io.Closer in values. The first error
+// is returned.
+func closeAll(values []interface{}) error {
+	var firstErr error
+	for _, v := range distinctValues(values) {
+		if c, ok := v.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// outputs returns the writers of the logger. The logger mutex must be
+// held.
+func (log *Logger) outputs() []interface{} {
+	writers := []interface{}{log.out, log.errOut}
+	for _, w := range log.levelOutputs {
+		writers = append(writers, w)
+	}
+
+	return writers
+}
+
+// Flush writes the entries queued for asynchronous logging and then
+// flushes the outputs that implement Flusher, e.g., *bufio.Writer, and
+// syncs those that implement Syncer, e.g., *os.File. os.Stdout and
+// os.Stderr are not synced. Hooks are not flushed; the sinks, e.g.,
+// LokiSink, have a Flush of their own and are flushed on exit. The
+// first error is returned.
+func (log *Logger) Flush() error {
+	if q := log.asyncQueue(); q != nil {
+		q.flush()
+	}
+
+	log.writeMu.Lock()
+	defer log.writeMu.Unlock()
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	return syncAll(log.outputs())
+}
+
+// Close returns the logger to synchronous logging, flushes it, and
+// closes the outputs that implement io.Closer. os.Stdout and os.Stderr
+// are not closed, nor are the hooks since they may be shared with
+// other loggers. The logger is removed from the loggers flushed on
+// exit. The first error is returned.
+func (log *Logger) Close() error {
+	log.DisableAsync()
+	UnregisterLogger(log)
+	err := log.Flush()
+
+	log.writeMu.Lock()
+	defer log.writeMu.Unlock()
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if closeErr := closeAll(log.outputs()); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// exit marks the logger to be flushed by HandleExit and panics with
+// Exit for HandleExit.
+func (log *Logger) exit(code int) {
+	markForExit(log)
+	panic(Exit{code})
+}
+
+// Flush flushes every logger that implements Flusher. The first error
+// is returned.
+func (logs *Loggers) Flush() error {
+	var members []interface{}
+	for _, logger := range logs.members() {
+		members = append(members, logger)
+	}
+
+	return syncAll(members)
+}
+
+// Close closes every logger that implements io.Closer and removes the
+// Loggers from the loggers flushed on exit. The first error is
+// returned.
+func (logs *Loggers) Close() error {
+	UnregisterLogger(logs)
+	var members []interface{}
+	for _, logger := range logs.members() {
+		members = append(members, logger)
+	}
+
+	return closeAll(members)
+}
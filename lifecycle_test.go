@@ -0,0 +1,177 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// bufferedOutput is a buffered output that records whether it has
+// been closed.
+type bufferedOutput struct {
+	*bufio.Writer
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newBufferedOutput() *bufferedOutput {
+	w := &bufferedOutput{}
+	w.Writer = bufio.NewWriter(&w.buf)
+
+	return w
+}
+
+func (w *bufferedOutput) Close() error {
+	w.closed = true
+	return nil
+}
+
+// blockingFlusher is a logger whose Flush never returns.
+type blockingFlusher struct{}
+
+func (blockingFlusher) Flush() error {
+	select {}
+}
+
+func newBufferedLogger(t *testing.T, w *bufferedOutput) *conlog.Logger {
+	formatter, err := conlog.NewTemplateFormatter("{{.Message}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := conlog.NewLogger()
+	log.SetFormatter(formatter)
+	log.SetOutput(w)
+	log.SetErrorOutput(w)
+
+	return log
+}
+
+func TestLogger_FlushClose(t *testing.T) {
+	w := newBufferedOutput()
+	log := newBufferedLogger(t, w)
+
+	log.Info("Info test")
+	assert.Empty(t, w.buf.String())
+	assert.NoError(t, log.Flush())
+	cmp := "Info test\n"
+	t.Logf("out = %q", w.buf.String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, w.buf.String())
+	assert.False(t, w.closed)
+
+	log.Error("Error test")
+	assert.NoError(t, log.Close())
+	cmp = "Info test\nError test\n"
+	t.Logf("out = %q", w.buf.String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, w.buf.String())
+	assert.True(t, w.closed)
+}
+
+func TestLogger_FatalFlush(t *testing.T) {
+	w := newBufferedOutput()
+	log := newBufferedLogger(t, w)
+
+	log.Info("Info test")
+	func() {
+		defer func() {
+			assert.Equal(t, conlog.Exit{Code: 2}, recover())
+		}()
+		log.FatalWithExitCode(2, "Fatal test")
+	}()
+	assert.Empty(t, w.buf.String())
+
+	// HandleExit flushes the logger, which is not registered, as
+	// it logged a Fatal message.
+	func() {
+		defer conlog.HandleExit()
+	}()
+	cmp := "Info test\nFatal test\n"
+	t.Logf("out = %q", w.buf.String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, w.buf.String())
+}
+
+func TestLoggers_FlushClose(t *testing.T) {
+	w1 := newBufferedOutput()
+	w2 := newBufferedOutput()
+	logs := conlog.NewLoggers(newBufferedLogger(t, w1), newBufferedLogger(t, w2))
+
+	logs.Info("Info test")
+	assert.Empty(t, w1.buf.String())
+	assert.NoError(t, logs.Flush())
+	assert.Equal(t, "Info test\n", w1.buf.String())
+	assert.Equal(t, "Info test\n", w2.buf.String())
+
+	func() {
+		defer func() {
+			assert.Equal(t, conlog.Exit{Code: 3}, recover())
+		}()
+		logs.FatalWithExitCode(3, "Fatal test")
+	}()
+	assert.Equal(t, "Info test\n", w1.buf.String())
+	assert.NoError(t, conlog.FlushLoggers())
+	cmp := "Info test\nFatal test\n"
+	assert.Equal(t, cmp, w1.buf.String())
+	assert.Equal(t, cmp, w2.buf.String())
+
+	assert.NoError(t, logs.Close())
+	assert.True(t, w1.closed)
+	assert.True(t, w2.closed)
+}
+
+func TestHandleExit_FlushLoggers(t *testing.T) {
+	w := newBufferedOutput()
+	log := newBufferedLogger(t, w)
+	conlog.RegisterLogger(log)
+	defer conlog.UnregisterLogger(log)
+
+	func() {
+		defer conlog.HandleExit()
+		log.Info("Info test")
+	}()
+	cmp := "Info test\n"
+	t.Logf("out = %q", w.buf.String())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, w.buf.String())
+}
+
+func TestFlushLoggers_Timeout(t *testing.T) {
+	defer conlog.SetFlushTimeout(conlog.GetFlushTimeout())
+	conlog.SetFlushTimeout(10 * time.Millisecond)
+	var flusher blockingFlusher
+	conlog.RegisterLogger(flusher)
+	defer conlog.UnregisterLogger(flusher)
+
+	start := time.Now()
+	assert.Equal(t, conlog.ErrFlushTimeout, conlog.FlushLoggers())
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestFlushLoggers_Sink(t *testing.T) {
+	server := newHTTPSinkServer()
+	defer server.Close()
+	options := conlog.NewHTTPSinkOptions(server.URL)
+	options.Formatter = newHTTPSinkFormatter()
+	options.BatchFormat = conlog.HTTPBatchFormatLines
+	options.BatchInterval = time.Hour
+	log, sink := newHTTPSinkLogger(t, options)
+
+	// The sink flushes itself on exit until it is closed.
+	log.Info("Exit test")
+	assert.Empty(t, server.requests())
+	assert.NoError(t, conlog.FlushLoggers())
+	cmp := []string{"level=info msg=\"Exit test\"\n"}
+	t.Logf("out = %q", server.requests())
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, server.requests())
+	assert.NoError(t, sink.Close())
+}
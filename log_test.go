@@ -3,6 +3,9 @@ package conlog_test
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -43,6 +46,8 @@ type testPrintf struct {
 func TestLog_PrintStyle(t *testing.T) {
 	logger, out, errOut := newSimpleLogger(conlog.DebugLevel)
 	var outTests = []testPrint{
+		{"Trace", logger.Trace, conlog.TraceLevel},
+		{"Traceln", logger.Traceln, conlog.TraceLevel},
 		{"Debug", logger.Debug, conlog.DebugLevel},
 		{"Debugln", logger.Debugln, conlog.DebugLevel},
 		{"Info", logger.Info, conlog.InfoLevel},
@@ -88,6 +93,7 @@ func TestLog_PrintStyle(t *testing.T) {
 func TestLog_PrintfStyle(t *testing.T) {
 	logger, out, errOut := newSimpleLogger(conlog.DebugLevel)
 	var outTests = []testPrintf{
+		{"Tracef", logger.Tracef, conlog.TraceLevel},
 		{"Debugf", logger.Debugf, conlog.DebugLevel},
 		{"Infof", logger.Infof, conlog.InfoLevel},
 		{"Warnf", logger.Warnf, conlog.WarnLevel},
@@ -330,3 +336,147 @@ func TestLog_PrintExceptions(t *testing.T) {
 	t.Logf("cmp string =     %q", cmpStr)
 	assert.Equal(t, cmpStr, out.String())
 }
+
+func TestLog_WithFields(t *testing.T) {
+	logger, out, errOut := newSimpleLogger(conlog.DebugLevel)
+
+	logger.WithField("file", "config.yaml").WithField("line", 42).Warn("Unknown key")
+	cmpStr := "WARN Unknown key file=config.yaml line=42\n"
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	logger.WithFields(conlog.Fields{"b": "two words", "a": ""}).Infof("Fields %s", "sorted")
+	cmpStr = "INFO Fields sorted a=\"\" b=\"two words\"\n"
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	logger.WithError(fmt.Errorf("permission denied")).Error("Cannot open file")
+	cmpStr = "ERRO Cannot open file error=\"permission denied\"\n"
+	t.Logf("errOut string = %q", errOut.String())
+	t.Logf("cmp string    = %q", cmpStr)
+	assert.Equal(t, cmpStr, errOut.String())
+	errOut.Reset()
+
+	// Fields are not added to Print* output.
+	logger.WithField("key", "value").Print("Print test")
+	cmpStr = "Print test"
+	assert.Equal(t, cmpStr, out.String())
+}
+
+func TestLog_ReportCaller(t *testing.T) {
+	logger, out, _ := newSimpleLogger(conlog.DebugLevel)
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.CallerFmt = conlog.CallerFormatShort
+	logger.SetFormatter(formatter)
+
+	// No caller is output unless ReportCaller is enabled.
+	logger.Info("No caller")
+	assert.Equal(t, "No caller\n", out.String())
+	out.Reset()
+
+	logger.SetReportCaller(true)
+	logger.Info("Logger caller")
+	_, file, line, _ := runtime.Caller(0)
+	cmpStr := fmt.Sprintf("%s:%d Logger caller\n", filepath.Base(file), line-1)
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	logger.WithField("key", "value").Infof("%s caller", "Entry")
+	_, file, line, _ = runtime.Caller(0)
+	cmpStr = fmt.Sprintf("%s:%d Entry caller key=value\n", filepath.Base(file), line-1)
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	loggers := conlog.NewLoggers(logger)
+	loggers.Infoln("Loggers caller")
+	_, file, line, _ = runtime.Caller(0)
+	cmpStr = fmt.Sprintf("%s:%d Loggers caller\n", filepath.Base(file), line-1)
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	formatter.Options.CallerFmt = conlog.CallerFormatFull
+	formatter.Options.LogLevelFmt = conlog.LogLevelFormatShort
+	logger.Warn("Full caller")
+	_, file, line, _ = runtime.Caller(0)
+	cmpStr = fmt.Sprintf("WARN %s:%d Full caller\n", file, line-1)
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+}
+
+func TestLog_ReportCallerStdLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.CallerFmt = conlog.CallerFormatShort
+	conlog.SetOutput(out)
+	conlog.SetFormatter(formatter)
+	conlog.SetReportCaller(true)
+	defer func() {
+		conlog.SetOutput(os.Stdout)
+		conlog.SetFormatter(conlog.NewStdFormatter())
+		conlog.SetReportCaller(false)
+	}()
+
+	conlog.Info("Std caller")
+	_, file, line, _ := runtime.Caller(0)
+	cmpStr := fmt.Sprintf("%s:%d Std caller\n", filepath.Base(file), line-1)
+	t.Logf("out string = %q", out.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+}
+
+func TestLog_LevelOutput(t *testing.T) {
+	logger, out, errOut := newSimpleLogger(conlog.DebugLevel)
+	warnOut := &bytes.Buffer{}
+	printOut := &bytes.Buffer{}
+
+	logger.SetLevelOutput(conlog.WarnLevel, warnOut)
+	logger.SetLevelOutput(conlog.PrintLevel, printOut)
+	assert.Equal(t, warnOut, logger.GetLevelOutput(conlog.WarnLevel))
+	assert.Equal(t, out, logger.GetLevelOutput(conlog.InfoLevel))
+	assert.Equal(t, errOut, logger.GetLevelOutput(conlog.ErrorLevel))
+	assert.Equal(t, out, logger.GetOutput())
+
+	logger.Info("Info")
+	logger.Warn("Warn")
+	logger.Log(auditLevel, "Audit") // Routed like its severity.
+	logger.Error("Error")
+	logger.Print("Print")
+	logger.V(0).Print("V")
+	assert.Equal(t, "INFO Info\n", out.String())
+	assert.Equal(t, "WARN Warn\nAUDT Audit\n", warnOut.String())
+	assert.Equal(t, "ERRO Error\n", errOut.String())
+	assert.Equal(t, "PrintV", printOut.String())
+	out.Reset()
+	errOut.Reset()
+
+	// Everything on stdout.
+	logger.SetLevelOutput(conlog.ErrorLevel, out)
+	logger.Error("Error")
+	assert.Equal(t, "ERRO Error\n", out.String())
+	assert.Empty(t, errOut.String())
+	out.Reset()
+
+	// SetOutput and SetErrorOutput replace the per-level writers
+	// of their levels.
+	logger.SetOutput(out)
+	logger.Warn("Warn")
+	logger.Print("Print")
+	logger.Error("Error")
+	assert.Equal(t, "WARN Warn\nPrintERRO Error\n", out.String())
+	out.Reset()
+	logger.SetErrorOutput(errOut)
+	logger.Error("Error")
+	assert.Equal(t, "ERRO Error\n", errOut.String())
+	assert.Empty(t, out.String())
+}
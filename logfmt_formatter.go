@@ -0,0 +1,126 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LogfmtFormatter formats logs as logfmt key=value lines, e.g.,
+//
+//	time=2019-01-02T15:04:05Z level=info msg="Starting up" port=8080
+//
+// The time, level, and msg keys (and func and file if the caller is
+// reported) always come first followed by the entry fields in lexical
+// order so output is easy to grep and diff. Fields with the same name
+// as one of those keys are prefixed with "fields.".
+type LogfmtFormatter struct {
+	// TimestampType controls the type of timestamp used. The
+	// default is TimestampTypeWall.
+	TimestampType TimestampType
+
+	// WallclockTimestampFmt is the time.Format() format used when
+	// displaying wall clock timestamps. Defaults to time.RFC3339.
+	WallclockTimestampFmt string
+
+	// ElapsedTimestampFmt is the format string used to display
+	// elapsed time timestamps. Defaults to "%04d".
+	ElapsedTimestampFmt string
+
+	// ShowPrintMessages controls whether output from the Print*()
+	// family of logging functions is written. It is dropped by
+	// default.
+	ShowPrintMessages bool
+
+	// FieldMap allows users to customize the names of keys for
+	// default fields.
+	FieldMap FieldMap
+}
+
+// NewLogfmtFormatter is the LogfmtFormatter constructor.
+func NewLogfmtFormatter() *LogfmtFormatter {
+	return &LogfmtFormatter{
+		TimestampType:         TimestampTypeWall,
+		WallclockTimestampFmt: DefaultWallclockTimestampFormat,
+		ElapsedTimestampFmt:   DefaultElapsedTimestampFormat,
+	}
+}
+
+// Format renders a single log entry.
+func (f *LogfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	if entry.Level == PrintLevel && !f.ShowPrintMessages {
+		return []byte{}, nil
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	switch f.TimestampType {
+	case TimestampTypeWall:
+		timestampFormat := f.WallclockTimestampFmt
+		if timestampFormat == "" {
+			timestampFormat = DefaultWallclockTimestampFormat
+		}
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyTime), entry.Time.Format(timestampFormat))
+	case TimestampTypeElapsed:
+		timestampFormat := f.ElapsedTimestampFmt
+		if timestampFormat == "" {
+			timestampFormat = DefaultElapsedTimestampFormat
+		}
+		ticks := int(entry.Time.Sub(baseTimestamp) / time.Second)
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyTime), fmt.Sprintf(timestampFormat, ticks))
+	default:
+	}
+	f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLevel), entry.Level.String())
+	f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyMsg), strings.TrimSuffix(entry.Message, "\n"))
+	if entry.HasCaller() {
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFunc), entry.Caller.Function)
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFile), fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line))
+	}
+	fields := make(Fields, len(entry.Fields))
+	for k, v := range entry.Fields {
+		fields[k] = v
+	}
+	prefixFieldClashes(fields, f.FieldMap)
+	for _, key := range fields.sortedKeys() {
+		f.appendKeyValue(b, key, fields[key])
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+func (f *LogfmtFormatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+	b.WriteString(formatFieldValue(value))
+}
+
+// logfmtKey replaces the characters that cannot appear in a logfmt
+// key with underscores.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtFormatter_Format(t *testing.T) {
+	formatter := conlog.NewLogfmtFormatter()
+	formatter.TimestampType = conlog.TimestampTypeNone
+
+	var tests = []struct {
+		Name   string
+		Msg    string
+		Fields conlog.Fields
+		CmpStr string
+	}{
+		{"plain", "Started\n", nil, "level=info msg=Started\n"},
+		{"spaces", "Listening on port\n", conlog.Fields{"port": 8080}, "level=info msg=\"Listening on port\" port=8080\n"},
+		{"quotes", "say \"hi\"\n", nil, "level=info msg=\"say \\\"hi\\\"\"\n"},
+		{"newlines", "line 1\nline 2\n", nil, "level=info msg=\"line 1\\nline 2\"\n"},
+		{"nonprintable", "bell\x07\xff\n", nil, "level=info msg=\"bell\\a\\xff\"\n"},
+		{"equals", "a=b\n", nil, "level=info msg=\"a=b\"\n"},
+		{"empty", "\n", conlog.Fields{"user": ""}, "level=info msg=\"\" user=\"\"\n"},
+		{"ordering", "Sorted\n", conlog.Fields{"z": 1, "a": 2, "m y": 3}, "level=info msg=Sorted a=2 m_y=3 z=1\n"},
+		{"clashes", "Clash\n", conlog.Fields{"level": "x", "msg": "y"}, "level=info msg=Clash fields.level=x fields.msg=y\n"},
+	}
+
+	for _, test := range tests {
+		entry := conlog.NewEntry(conlog.NewLogger())
+		entry.Level = conlog.InfoLevel
+		entry.Message = test.Msg
+		entry.Fields = test.Fields
+		out, err := formatter.Format(entry)
+		t.Logf("test: %s", test.Name)
+		t.Logf("out string = %q", string(out))
+		t.Logf("cmp string = %q", test.CmpStr)
+		assert.NoError(t, err)
+		assert.Equal(t, test.CmpStr, string(out))
+	}
+}
+
+func TestLogfmtFormatter_Timestamps(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := conlog.NewLogger()
+	log.SetOutput(out)
+	formatter := conlog.NewLogfmtFormatter()
+	formatter.WallclockTimestampFmt = "2006"
+	log.SetFormatter(formatter)
+
+	log.Info("Wall clock")
+	cmpStr := "time=" + time.Now().Format("2006") + " level=info msg=\"Wall clock\"\n"
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	formatter.TimestampType = conlog.TimestampTypeElapsed
+	log.Info("Elapsed")
+	assert.Regexp(t, "^time=[0-9]{4} level=info msg=Elapsed\n$", out.String())
+	out.Reset()
+
+	// Print* output is dropped unless requested.
+	log.Print("Dropped")
+	assert.Empty(t, out.String())
+}
+
+func TestLogfmtFormatter_ZeroValue(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := conlog.NewLogger()
+	log.SetOutput(out)
+	formatter := &conlog.LogfmtFormatter{}
+	log.SetFormatter(formatter)
+
+	// No timestamp is written by default.
+	log.Info("Info test")
+	cmpStr := "level=info msg=\"Info test\"\n"
+	t.Logf("out = %q", out.String())
+	t.Logf("cmp = %q", cmpStr)
+	assert.Equal(t, cmpStr, out.String())
+	out.Reset()
+
+	// Empty timestamp formats fall back to the defaults.
+	formatter.TimestampType = conlog.TimestampTypeWall
+	log.Info("Wall clock")
+	t.Logf("out = %q", out.String())
+	assert.Regexp(t, "^time=[0-9]{4}-[0-9]{2}-[0-9]{2}T[^ ]+ level=info msg=\"Wall clock\"\n$", out.String())
+	out.Reset()
+
+	formatter.TimestampType = conlog.TimestampTypeElapsed
+	log.Info("Elapsed")
+	t.Logf("out = %q", out.String())
+	assert.Regexp(t, "^time=[0-9]{4} level=info msg=Elapsed\n$", out.String())
+}
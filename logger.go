@@ -5,6 +5,8 @@
 package conlog
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -17,11 +19,13 @@ import (
 var (
 	// DiscardLogger throws away all output.
 	DiscardLogger = &Logger{
-		out:          ioutil.Discard,
-		errOut:       ioutil.Discard,
-		formatter:    NewStdFormatter(),
-		level:        PanicLevel,
-		printEnabled: abool.New(),
+		out:             ioutil.Discard,
+		errOut:          ioutil.Discard,
+		formatter:       NewStdFormatter(),
+		level:           PanicLevel,
+		printEnabled:    abool.New(),
+		reportCaller:    abool.New(),
+		hookErrorPolicy: HookErrorPolicyReport,
 	}
 )
 
@@ -40,6 +44,10 @@ type Logger struct {
 	// just about any Writer.
 	errOut io.Writer
 
+	// Per-level writers set with SetLevelOutput. They override
+	// out and errOut.
+	levelOutputs map[Level]io.Writer
+
 	// All log entries pass through the formatter before being
 	// logged to out.
 	formatter Formatter
@@ -48,6 +56,15 @@ type Logger struct {
 	// logging functions.
 	printEnabled *abool.AtomicBool
 
+	// Print*() output is suppressed unless the verbosity is at
+	// least the tier of the message. Print() itself is tier 0,
+	// V(n).Print() is tier n.
+	verbosity int32
+
+	// If true, the calling file, line, and function are recorded
+	// in each entry.
+	reportCaller *abool.AtomicBool
+
 	// The logging level the logger should log at. This is
 	// typically conlog.InfoLevel, which allows Info(), Warn(),
 	// Error() and Fatal() to be logged. The default is InfoLevel
@@ -57,8 +74,27 @@ type Logger struct {
 	// Default.
 	mu MutexWrap
 
+	// Serializes writes to the outputs with flushing, closing,
+	// reopening, and replacing them. The writer goroutine of an
+	// async logger holds it, but not mu, while writing. It is
+	// always taken before mu.
+	writeMu MutexWrap
+
 	// Reusable empty entry
 	entryPool sync.Pool
+
+	// Hooks fired for each entry, keyed by level.
+	hooks LevelHooks
+
+	// What happens to an entry when a hook fails.
+	hookErrorPolicy HookErrorPolicy
+
+	// The *asyncQueue entries are written through when
+	// asynchronous logging is enabled. asyncMu serializes
+	// enabling and disabling it.
+	async        atomic.Value
+	asyncMu      sync.Mutex
+	asyncDropped uint64
 }
 
 // MutexWrap is used to serialize logging output amongst goroutines.
@@ -99,16 +135,24 @@ type Exit struct {
 // uses the panic/recover mechanism to defer exiting. This routing
 // should be used in your main routine like so:
 //
-// func main() {
-//    defer handleExit()
-//    // ready to go
-// }
+//	func main() {
+//	   defer handleExit()
+//	   // ready to go
+//	}
 //
 // See
 // https://stackoverflow.com/questions/27629380/how-to-exit-a-go-program-honoring-deferred-calls
 // for details.
+//
+// The loggers registered with RegisterLogger and the loggers that
+// logged a Fatal*() message are flushed, and then the functions
+// registered with RegisterExitHandler are run, before HandleExit
+// returns, exits, or re-panics. HandleExit waits for them at most the
+// flush timeout set with SetFlushTimeout.
 func HandleExit() {
-	if e := recover(); e != nil {
+	e := recover()
+	flushOnExit()
+	if e != nil {
 		if exit, ok := e.(Exit); ok {
 			os.Exit(exit.Code)
 		}
@@ -116,22 +160,58 @@ func HandleExit() {
 	}
 }
 
+var (
+	exitHandlersMu sync.Mutex
+	exitHandlers   []func()
+)
+
+// RegisterExitHandler registers a function run by HandleExit, e.g.,
+// to flush buffered log entries so Fatal*() messages are delivered
+// before the program exits. Handlers are run in the order they were
+// registered.
+func RegisterExitHandler(handler func()) {
+	exitHandlersMu.Lock()
+	exitHandlers = append(exitHandlers, handler)
+	exitHandlersMu.Unlock()
+}
+
+// runExitHandlers runs the registered exit handlers. A panicking
+// handler does not prevent the others from running.
+func runExitHandlers() {
+	exitHandlersMu.Lock()
+	handlers := exitHandlers
+	exitHandlersMu.Unlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			handler()
+		}()
+	}
+}
+
 // NewLogger is the constructor for Logger.
 func NewLogger() *Logger {
 	log := &Logger{
-		out:          os.Stdout,
-		errOut:       os.Stderr,
-		formatter:    NewStdFormatter(),
-		level:        InfoLevel,
-		printEnabled: abool.New(),
+		out:             os.Stdout,
+		errOut:          os.Stderr,
+		formatter:       NewStdFormatter(),
+		level:           InfoLevel,
+		printEnabled:    abool.New(),
+		reportCaller:    abool.New(),
+		hooks:           make(LevelHooks),
+		hookErrorPolicy: HookErrorPolicyReport,
 	}
 	log.printEnabled.Set()
 
 	return log
 }
 
-// GetOutput returns the writer used for Print, Debug, and Warning
-// messages.
+// GetOutput returns the writer used for Print, Trace, Debug, Info,
+// and Warning messages that are not routed elsewhere with
+// SetLevelOutput.
 func (log *Logger) GetOutput() io.Writer {
 	log.mu.Lock()
 	defer log.mu.Unlock()
@@ -139,16 +219,21 @@ func (log *Logger) GetOutput() io.Writer {
 	return log.out
 }
 
-// SetOutput sets the writer used for Print, Debug, and Warning
-// messages.
+// SetOutput sets the writer used for Print, Trace, Debug, Info, and
+// Warning messages. It is shorthand for calling SetLevelOutput for
+// each of those levels and replaces any writers previously set for
+// them.
 func (log *Logger) SetOutput(w io.Writer) {
+	log.writeMu.Lock()
 	log.mu.Lock()
 	log.out = w
+	log.clearLevelOutputs(false)
 	log.mu.Unlock()
+	log.writeMu.Unlock()
 }
 
 // GetErrorOutput returns the writer used for Error, Fatal, and Panic
-// messages.
+// messages that are not routed elsewhere with SetLevelOutput.
 func (log *Logger) GetErrorOutput() io.Writer {
 	log.mu.Lock()
 	defer log.mu.Unlock()
@@ -157,11 +242,68 @@ func (log *Logger) GetErrorOutput() io.Writer {
 }
 
 // SetErrorOutput sets the writer used for Error, Fatal, and Panic
-// messages.
+// messages. It is shorthand for calling SetLevelOutput for each of
+// those levels and replaces any writers previously set for them.
 func (log *Logger) SetErrorOutput(w io.Writer) {
+	log.writeMu.Lock()
 	log.mu.Lock()
 	log.errOut = w
+	log.clearLevelOutputs(true)
 	log.mu.Unlock()
+	log.writeMu.Unlock()
+}
+
+// SetLevelOutput sets the writer used for messages at level. Use
+// PrintLevel to route Print*() output. User-defined levels without a
+// writer of their own use the writer of their severity. For example,
+// to send warnings to stderr so stdout only contains data:
+//
+//	log.SetLevelOutput(conlog.WarnLevel, os.Stderr)
+func (log *Logger) SetLevelOutput(level Level, w io.Writer) {
+	log.writeMu.Lock()
+	log.mu.Lock()
+	if log.levelOutputs == nil {
+		log.levelOutputs = make(map[Level]io.Writer)
+	}
+	log.levelOutputs[level] = w
+	log.mu.Unlock()
+	log.writeMu.Unlock()
+}
+
+// GetLevelOutput returns the writer used for messages at level.
+func (log *Logger) GetLevelOutput(level Level) io.Writer {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	return log.writerFor(level)
+}
+
+// writerFor returns the writer used for messages at level. The caller
+// must hold the logger mutex.
+func (log *Logger) writerFor(level Level) io.Writer {
+	if w, ok := log.levelOutputs[level]; ok {
+		return w
+	}
+	severity := level.severity()
+	if w, ok := log.levelOutputs[severity]; ok {
+		return w
+	}
+	if severity <= ErrorLevel {
+		return log.errOut
+	}
+
+	return log.out
+}
+
+// clearLevelOutputs removes the per-level writers for either the
+// error levels (Error, Fatal, and Panic) or the remaining levels. The
+// caller must hold the logger mutex.
+func (log *Logger) clearLevelOutputs(errorLevels bool) {
+	for level := range log.levelOutputs {
+		if (level.severity() <= ErrorLevel) == errorLevels {
+			delete(log.levelOutputs, level)
+		}
+	}
 }
 
 // SetLevel sets the logger level.
@@ -174,6 +316,12 @@ func (log *Logger) GetLevel() Level {
 	return Level(atomic.LoadUint32((*uint32)(&log.level)))
 }
 
+// IsLevelEnabled returns true if messages at level are output by the
+// logger.
+func (log *Logger) IsLevelEnabled(level Level) bool {
+	return log.GetLevel().severity() >= level.severity()
+}
+
 // SetPrintEnabled sets the PrintEnabled setting.
 func (log *Logger) SetPrintEnabled(enabled bool) {
 	log.printEnabled.SetTo(enabled)
@@ -184,6 +332,50 @@ func (log *Logger) GetPrintEnabled() bool {
 	return log.printEnabled.IsSet()
 }
 
+// SetVerbosity sets the verbosity used to filter Print*-style
+// output. Print, Printf, and Println are output when the verbosity is
+// at least 0 and V(n).Print* when it is at least n. The default
+// verbosity is 0. Command-line programs typically map -v, -vv, and
+// -vvv onto verbosities 1, 2, and 3.
+func (log *Logger) SetVerbosity(verbosity int) {
+	atomic.StoreInt32(&log.verbosity, int32(verbosity))
+}
+
+// GetVerbosity returns the current verbosity.
+func (log *Logger) GetVerbosity() int {
+	return int(atomic.LoadInt32(&log.verbosity))
+}
+
+// isPrintEnabled returns true if Print*-style output at verbosity
+// tier v is output.
+func (log *Logger) isPrintEnabled(v int) bool {
+	return log.GetPrintEnabled() && log.GetVerbosity() >= v
+}
+
+// V returns a Verbose whose Print* methods output only if printing is
+// enabled and the verbosity is at least v, e.g.,
+//
+//	log.V(2).Printf("Sent %d bytes\n", n)
+func (log *Logger) V(v int) Verbose {
+	if log.isPrintEnabled(v) {
+		return Verbose{loggers: []*Logger{log}}
+	}
+
+	return Verbose{}
+}
+
+// SetReportCaller enables/disables recording the calling file, line,
+// and function in each entry. It is disabled by default as looking up
+// the caller is relatively expensive.
+func (log *Logger) SetReportCaller(enabled bool) {
+	log.reportCaller.SetTo(enabled)
+}
+
+// GetReportCaller returns the ReportCaller setting.
+func (log *Logger) GetReportCaller() bool {
+	return log.reportCaller.IsSet()
+}
+
 // SetFormatter sets the formatter used when printing entries.
 func (log *Logger) SetFormatter(formatter Formatter) {
 	log.mu.Lock()
@@ -191,11 +383,54 @@ func (log *Logger) SetFormatter(formatter Formatter) {
 	log.mu.Unlock()
 }
 
+// AddHook adds a hook to the logger. The hook is fired before each
+// entry at one of the hook's levels is written.
+func (log *Logger) AddHook(hook Hook) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	hooks := make(LevelHooks, len(log.hooks))
+	for level, levelHooks := range log.hooks {
+		hooks[level] = append([]Hook(nil), levelHooks...)
+	}
+	hooks.Add(hook)
+	log.hooks = hooks
+}
+
+// RemoveHooks removes all hooks from the logger. It returns the hooks
+// that were removed.
+func (log *Logger) RemoveHooks() LevelHooks {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	hooks := log.hooks
+	log.hooks = make(LevelHooks)
+
+	return hooks
+}
+
+// SetHookErrorPolicy sets what happens to an entry when one of its
+// hooks returns an error. The default is HookErrorPolicyReport.
+func (log *Logger) SetHookErrorPolicy(policy HookErrorPolicy) {
+	log.mu.Lock()
+	log.hookErrorPolicy = policy
+	log.mu.Unlock()
+}
+
+// GetHookErrorPolicy returns the HookErrorPolicy setting.
+func (log *Logger) GetHookErrorPolicy() HookErrorPolicy {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	return log.hookErrorPolicy
+}
+
 // SetNoLock disables the use of locking. It can be used when the log
 // files are opened with appending mode, It is then safe to write
 // concurrently to a file (within 4k message on Linux).
 func (log *Logger) SetNoLock() {
 	log.mu.Disable()
+	log.writeMu.Disable()
 }
 
 // Lock temporarily blocks output.
@@ -217,16 +452,108 @@ func (log *Logger) newEntry() *Entry {
 	return NewEntry(log)
 }
 
+// newExitEntry returns an entry for a Fatal*() message that exits
+// with code. It is not taken from the pool as it carries the exit
+// code.
+func (log *Logger) newExitEntry(code int) *Entry {
+	entry := NewEntry(log)
+	if code >= 0 {
+		entry.ExitCode = &code
+	}
+
+	return entry
+}
+
+// logExitCode logs msg at FatalLevel on an entry carrying code
+// without exiting. It is used by Loggers so the members see the exit
+// code.
+func (log *Logger) logExitCode(code int, msg string) {
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).logNoExit(FatalLevel, msg)
+	}
+}
+
 // releaseEntry returns an entry to the pool.
 func (log *Logger) releaseEntry(entry *Entry) {
 	log.entryPool.Put(entry)
 }
 
+// WithField creates an entry from the logger and adds a field to
+// it. Use the returned Entry to log the message, e.g.,
+// log.WithField("file", name).Warn("file is empty").
+func (log *Logger) WithField(key string, value interface{}) *Entry {
+	entry := log.newEntry()
+	defer log.releaseEntry(entry)
+
+	return entry.WithField(key, value)
+}
+
+// WithFields creates an entry from the logger and adds multiple
+// fields to it.
+func (log *Logger) WithFields(fields Fields) *Entry {
+	entry := log.newEntry()
+	defer log.releaseEntry(entry)
+
+	return entry.WithFields(fields)
+}
+
+// WithError creates an entry from the logger and adds err to it
+// using ErrorKey as the key.
+func (log *Logger) WithError(err error) *Entry {
+	entry := log.newEntry()
+	defer log.releaseEntry(entry)
+
+	return entry.WithError(err)
+}
+
+// WithContext creates an entry from the logger and adds ctx to it.
+// Hooks such as OTLPSink read trace and span IDs from the context.
+func (log *Logger) WithContext(ctx context.Context) *Entry {
+	entry := log.newEntry()
+	defer log.releaseEntry(entry)
+
+	return entry.WithContext(ctx)
+}
+
+// Log logs a message at level on the logger. Arguments are handled in
+// the manner of fmt.Print and a newline is appended. It is typically
+// used with levels added with RegisterLevel. Unlike Fatal and Panic,
+// Log never exits or panics, even at FatalLevel or PanicLevel.
+func (log *Logger) Log(level Level, args ...interface{}) {
+	if log.IsLevelEnabled(level) {
+		entry := log.newEntry()
+		args = append(args, "\n")
+		entry.logNoExit(level, fmt.Sprint(args...))
+		log.releaseEntry(entry)
+	}
+}
+
+// Logf logs a message at level on the logger. Arguments are handled
+// in the manner of fmt.Printf and a newline is appended. Like Log, it
+// never exits or panics.
+func (log *Logger) Logf(level Level, format string, args ...interface{}) {
+	if log.IsLevelEnabled(level) {
+		entry := log.newEntry()
+		entry.logNoExit(level, fmt.Sprintf(format+"\n", args...))
+		log.releaseEntry(entry)
+	}
+}
+
+// Logln logs a message at level on the logger. Arguments are handled
+// in the manner of fmt.Println. Like Log, it never exits or panics.
+func (log *Logger) Logln(level Level, args ...interface{}) {
+	if log.IsLevelEnabled(level) {
+		entry := log.newEntry()
+		entry.logNoExit(level, fmt.Sprintln(args...))
+		log.releaseEntry(entry)
+	}
+}
+
 // Print prints a message to the logger. It ignores logging levels. No
 // logging levels, or timestamps are added. No newline is added. The
 // equivalent of fmt.Fprint(out, ...).
 func (log *Logger) Print(args ...interface{}) {
-	if log.GetPrintEnabled() {
+	if log.isPrintEnabled(0) {
 		entry := log.newEntry()
 		entry.Print(args...)
 		log.releaseEntry(entry)
@@ -237,7 +564,7 @@ func (log *Logger) Print(args ...interface{}) {
 // logging levels, or timestamps are added. The equivalent of
 // fmt.Fprintf(out, ...).
 func (log *Logger) Printf(format string, args ...interface{}) {
-	if log.GetPrintEnabled() {
+	if log.isPrintEnabled(0) {
 		entry := log.newEntry()
 		entry.Printf(format, args...)
 		log.releaseEntry(entry)
@@ -248,16 +575,45 @@ func (log *Logger) Printf(format string, args ...interface{}) {
 // logging levels, or timestamps, are added. The equivalent of
 // fmt.Fprintln(out, ...).
 func (log *Logger) Println(args ...interface{}) {
-	if log.GetPrintEnabled() {
+	if log.isPrintEnabled(0) {
 		entry := log.newEntry()
 		entry.Println(args...)
 		log.releaseEntry(entry)
 	}
 }
 
+// Trace logs a message at level Trace on the logger.
+func (log *Logger) Trace(args ...interface{}) {
+	if log.IsLevelEnabled(TraceLevel) {
+		entry := log.newEntry()
+		entry.Trace(args...)
+		log.releaseEntry(entry)
+	}
+}
+
+// Tracef logs a message at level Trace on the logger. Arguments are
+// handled in the manner of fmt.Printf.
+func (log *Logger) Tracef(format string, args ...interface{}) {
+	if log.IsLevelEnabled(TraceLevel) {
+		entry := log.newEntry()
+		entry.Tracef(format, args...)
+		log.releaseEntry(entry)
+	}
+}
+
+// Traceln logs a message at level Trace on the logger.  It is
+// equivalent to Trace().
+func (log *Logger) Traceln(args ...interface{}) {
+	if log.IsLevelEnabled(TraceLevel) {
+		entry := log.newEntry()
+		entry.Traceln(args...)
+		log.releaseEntry(entry)
+	}
+}
+
 // Debug logs a message at level Debug on the logger.
 func (log *Logger) Debug(args ...interface{}) {
-	if log.GetLevel() >= DebugLevel {
+	if log.IsLevelEnabled(DebugLevel) {
 		entry := log.newEntry()
 		entry.Debug(args...)
 		log.releaseEntry(entry)
@@ -267,7 +623,7 @@ func (log *Logger) Debug(args ...interface{}) {
 // Debugf logs a message at level Debug on the logger. Arguments are
 // handled in the manner of fmt.Printf.
 func (log *Logger) Debugf(format string, args ...interface{}) {
-	if log.GetLevel() >= DebugLevel {
+	if log.IsLevelEnabled(DebugLevel) {
 		entry := log.newEntry()
 		entry.Debugf(format, args...)
 		log.releaseEntry(entry)
@@ -277,7 +633,7 @@ func (log *Logger) Debugf(format string, args ...interface{}) {
 // Debugln logs a message at level Debug on the logger.  It is
 // equivalent to Debug().
 func (log *Logger) Debugln(args ...interface{}) {
-	if log.GetLevel() >= DebugLevel {
+	if log.IsLevelEnabled(DebugLevel) {
 		entry := log.newEntry()
 		entry.Debugln(args...)
 		log.releaseEntry(entry)
@@ -286,7 +642,7 @@ func (log *Logger) Debugln(args ...interface{}) {
 
 // Info logs a message at level Info on the logger.
 func (log *Logger) Info(args ...interface{}) {
-	if log.GetLevel() >= InfoLevel {
+	if log.IsLevelEnabled(InfoLevel) {
 		entry := log.newEntry()
 		entry.Info(args...)
 		log.releaseEntry(entry)
@@ -296,7 +652,7 @@ func (log *Logger) Info(args ...interface{}) {
 // Infof logs a message at level Info on the logger. Arguments are
 // handled in the manner of fmt.Printf.
 func (log *Logger) Infof(format string, args ...interface{}) {
-	if log.GetLevel() >= InfoLevel {
+	if log.IsLevelEnabled(InfoLevel) {
 		entry := log.newEntry()
 		entry.Infof(format, args...)
 		log.releaseEntry(entry)
@@ -306,7 +662,7 @@ func (log *Logger) Infof(format string, args ...interface{}) {
 // Infoln logs a message at level Info on logger. It is equivalent to
 // Info().
 func (log *Logger) Infoln(args ...interface{}) {
-	if log.GetLevel() >= InfoLevel {
+	if log.IsLevelEnabled(InfoLevel) {
 		entry := log.newEntry()
 		entry.Infoln(args...)
 		log.releaseEntry(entry)
@@ -315,7 +671,7 @@ func (log *Logger) Infoln(args ...interface{}) {
 
 // Warn logs a message at level Warn on the logger.
 func (log *Logger) Warn(args ...interface{}) {
-	if log.GetLevel() >= WarnLevel {
+	if log.IsLevelEnabled(WarnLevel) {
 		entry := log.newEntry()
 		entry.Warn(args...)
 		log.releaseEntry(entry)
@@ -325,7 +681,7 @@ func (log *Logger) Warn(args ...interface{}) {
 // Warnf logs a message at level Warn on the logger. Arguments are
 // handled in the manner of fmt.Printf.
 func (log *Logger) Warnf(format string, args ...interface{}) {
-	if log.GetLevel() >= WarnLevel {
+	if log.IsLevelEnabled(WarnLevel) {
 		entry := log.newEntry()
 		entry.Warnf(format, args...)
 		log.releaseEntry(entry)
@@ -335,7 +691,7 @@ func (log *Logger) Warnf(format string, args ...interface{}) {
 // Warnln logs a message at level Warn on the logger. It is equivlent
 // to Warn().
 func (log *Logger) Warnln(args ...interface{}) {
-	if log.GetLevel() >= WarnLevel {
+	if log.IsLevelEnabled(WarnLevel) {
 		entry := log.newEntry()
 		entry.Warnln(args...)
 		log.releaseEntry(entry)
@@ -345,7 +701,7 @@ func (log *Logger) Warnln(args ...interface{}) {
 // Warning logs a message at level Warn on the logger. Warning is an
 // alias for Warn.
 func (log *Logger) Warning(args ...interface{}) {
-	if log.GetLevel() >= WarnLevel {
+	if log.IsLevelEnabled(WarnLevel) {
 		entry := log.newEntry()
 		entry.Warn(args...)
 		log.releaseEntry(entry)
@@ -356,7 +712,7 @@ func (log *Logger) Warning(args ...interface{}) {
 // handled in the manner of fmt.Printf. Warningf is an an alias for
 // Warnf.
 func (log *Logger) Warningf(format string, args ...interface{}) {
-	if log.GetLevel() >= WarnLevel {
+	if log.IsLevelEnabled(WarnLevel) {
 		entry := log.newEntry()
 		entry.Warnf(format, args...)
 		log.releaseEntry(entry)
@@ -366,7 +722,7 @@ func (log *Logger) Warningf(format string, args ...interface{}) {
 // Warningln logs a message at level Warn on the logger. It is
 // equivlent to Warning(). Warningln is an alias for Warnln.
 func (log *Logger) Warningln(args ...interface{}) {
-	if log.GetLevel() >= WarnLevel {
+	if log.IsLevelEnabled(WarnLevel) {
 		entry := log.newEntry()
 		entry.Warnln(args...)
 		log.releaseEntry(entry)
@@ -375,7 +731,7 @@ func (log *Logger) Warningln(args ...interface{}) {
 
 // Error logs a message at level Error on the logger.
 func (log *Logger) Error(args ...interface{}) {
-	if log.GetLevel() >= ErrorLevel {
+	if log.IsLevelEnabled(ErrorLevel) {
 		entry := log.newEntry()
 		entry.Error(args...)
 		log.releaseEntry(entry)
@@ -385,7 +741,7 @@ func (log *Logger) Error(args ...interface{}) {
 // Errorf logs a message at level Error on the logger. Arguments are
 // handled in the manner of fmt.Printf.
 func (log *Logger) Errorf(format string, args ...interface{}) {
-	if log.GetLevel() >= ErrorLevel {
+	if log.IsLevelEnabled(ErrorLevel) {
 		entry := log.newEntry()
 		entry.Errorf(format, args...)
 		log.releaseEntry(entry)
@@ -395,7 +751,7 @@ func (log *Logger) Errorf(format string, args ...interface{}) {
 // Errorln logs a message at level Error on the logger. It is
 // equivalent to Error().
 func (log *Logger) Errorln(args ...interface{}) {
-	if log.GetLevel() >= ErrorLevel {
+	if log.IsLevelEnabled(ErrorLevel) {
 		entry := log.newEntry()
 		entry.Errorln(args...)
 		log.releaseEntry(entry)
@@ -405,47 +761,39 @@ func (log *Logger) Errorln(args ...interface{}) {
 // Fatal logs a message at level Fatal on the logger and exits with
 // the DefaultExitCode.
 func (log *Logger) Fatal(args ...interface{}) {
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatal(args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(DefaultExitCode).Fatal(args...)
 	}
-	panic(Exit{DefaultExitCode})
+	log.exit(DefaultExitCode)
 }
 
 // Fatalf logs a message at level Fatal on the logger and exits with
 // the DefaultExitCode. Arguments are handled in the manner of
 // fmt.Printf.
 func (log *Logger) Fatalf(format string, args ...interface{}) {
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatalf(format, args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(DefaultExitCode).Fatalf(format, args...)
 	}
-	panic(Exit{DefaultExitCode})
+	log.exit(DefaultExitCode)
 }
 
 // Fatalln logs a message at level Fatal on the logger and exits with
 // the DefaultExitCode. It is equivalent to Fatal().
 func (log *Logger) Fatalln(args ...interface{}) {
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatalln(args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(DefaultExitCode).Fatalln(args...)
 	}
-	panic(Exit{DefaultExitCode})
+	log.exit(DefaultExitCode)
 }
 
 // FatalWithExitCode logs a message at level Fatal on the logger. It
 // then exits with the specified code if code >= 0.
 func (log *Logger) FatalWithExitCode(code int, args ...interface{}) {
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatal(args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).Fatal(args...)
 	}
 	if code >= 0 {
-		panic(Exit{code})
+		log.exit(code)
 	}
 }
 
@@ -453,26 +801,22 @@ func (log *Logger) FatalWithExitCode(code int, args ...interface{}) {
 // then exits with the specified code if code >= 0. Arguments are
 // handled in the manner of fmt.Printf.
 func (log *Logger) FatalfWithExitCode(code int, format string, args ...interface{}) {
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatalf(format, args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).Fatalf(format, args...)
 	}
 	if code >= 0 {
-		panic(Exit{code})
+		log.exit(code)
 	}
 }
 
 // FatallnWithExitCode logs a message at level Fatal on the logger. It
 // then exits with the specified exit code if code >= 0.
 func (log *Logger) FatallnWithExitCode(code int, args ...interface{}) {
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatalln(args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).Fatalln(args...)
 	}
 	if code >= 0 {
-		panic(Exit{code})
+		log.exit(code)
 	}
 }
 
@@ -483,13 +827,11 @@ func (log *Logger) FatalIfError(err error, code int, args ...interface{}) {
 	if err == nil {
 		return
 	}
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatal(args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).Fatal(args...)
 	}
 	if code >= 0 {
-		panic(Exit{code})
+		log.exit(code)
 	}
 }
 
@@ -500,13 +842,11 @@ func (log *Logger) FatalfIfError(err error, code int, format string, args ...int
 	if err == nil {
 		return
 	}
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatalf(format, args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).Fatalf(format, args...)
 	}
 	if code >= 0 {
-		panic(Exit{code})
+		log.exit(code)
 	}
 
 }
@@ -518,19 +858,17 @@ func (log *Logger) FatallnIfError(err error, code int, args ...interface{}) {
 	if err == nil {
 		return
 	}
-	if log.GetLevel() >= FatalLevel {
-		entry := log.newEntry()
-		entry.Fatalln(args...)
-		log.releaseEntry(entry)
+	if log.IsLevelEnabled(FatalLevel) {
+		log.newExitEntry(code).Fatalln(args...)
 	}
 	if code >= 0 {
-		panic(Exit{code})
+		log.exit(code)
 	}
 }
 
 // Panic logs a message at level Panic on the logger and then panics.
 func (log *Logger) Panic(args ...interface{}) {
-	if log.GetLevel() >= PanicLevel {
+	if log.IsLevelEnabled(PanicLevel) {
 		entry := log.newEntry()
 		entry.Panic(args...)
 		log.releaseEntry(entry)
@@ -540,7 +878,7 @@ func (log *Logger) Panic(args ...interface{}) {
 // Panicf logs a message at level Panic on the logger and then
 // panics. Arguments are handled in the manner of fmt.Printf.
 func (log *Logger) Panicf(format string, args ...interface{}) {
-	if log.GetLevel() >= PanicLevel {
+	if log.IsLevelEnabled(PanicLevel) {
 		entry := log.newEntry()
 		entry.Panicf(format, args...)
 		log.releaseEntry(entry)
@@ -550,7 +888,7 @@ func (log *Logger) Panicf(format string, args ...interface{}) {
 // Panicln logs a message at level Panic on the logger. It is
 // equivalent to Panic().
 func (log *Logger) Panicln(args ...interface{}) {
-	if log.GetLevel() >= PanicLevel {
+	if log.IsLevelEnabled(PanicLevel) {
 		entry := log.newEntry()
 		entry.Panicln(args...)
 		log.releaseEntry(entry)
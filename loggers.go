@@ -4,12 +4,91 @@
 
 package conlog
 
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Filter restricts the messages a Loggers forwards to one of its
+// members. The zero value forwards everything.
+type Filter struct {
+	// LevelFloor is the least verbose level the member is set to
+	// by Loggers.SetLevel. E.g., a member with a DebugLevel floor
+	// stays at DebugLevel when the Loggers are set to
+	// InfoLevel. The default, PanicLevel, imposes no floor.
+	LevelFloor Level
+
+	// Include, if not empty, lists the only levels forwarded to
+	// the member.
+	Include []Level
+
+	// Exclude lists levels never forwarded to the member.
+	Exclude []Level
+
+	// Predicate, if not nil, is called for every message that
+	// passes Include and Exclude. The message is forwarded only
+	// if it returns true. The Entry has only its Level, Message,
+	// and Time set. Predicate must not modify the Loggers.
+	Predicate func(entry *Entry) bool
+}
+
+// NewFilter is the constructor for Filter.
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+func (f *Filter) allowsLevel(level Level) bool {
+	if len(f.Include) > 0 && !containsLevel(f.Include, level) {
+		return false
+	}
+
+	return !containsLevel(f.Exclude, level)
+}
+
+func (f *Filter) floor(level Level) Level {
+	if f.LevelFloor.severity() > level.severity() {
+		return f.LevelFloor
+	}
+
+	return level
+}
+
+func containsLevel(levels []Level, level Level) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Loggers outputs the same message at the same log level to a list of
 // loggers. It is often used when you want the same message to go to
-// both the console and a log file.
+// both the console and a log file. Each logger keeps its own level,
+// formatter, and outputs; a Filter can further restrict what is
+// forwarded to it.
+//
+// Loggers implements ConLogger so it can be used wherever a single
+// logger is expected, including as a member of another Loggers.
 type Loggers struct {
-	// A list of Logger(s) to output to.
+	// A list of Logger(s) to output to. Use Add and Remove to
+	// change the list while other goroutines are logging.
 	Loggers []ConLogger
+
+	mu      sync.RWMutex
+	filters []memberFilter
+}
+
+// memberFilter is the filter set for a member with SetFilter.
+type memberFilter struct {
+	logger ConLogger
+	filter *Filter
 }
 
 // NewLogs is the constructor for Logs.
@@ -19,271 +98,743 @@ func NewLoggers(loggers ...ConLogger) *Loggers {
 	}
 }
 
-// SetLevel sets the logger level for all loggers..
+var _ ConLogger = (*Loggers)(nil)
+
+// Add appends loggers to the list of loggers. It is safe to call
+// while other goroutines are logging.
+func (logs *Loggers) Add(loggers ...ConLogger) {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+
+	members := make([]ConLogger, 0, len(logs.Loggers)+len(loggers))
+	members = append(members, logs.Loggers...)
+	logs.Loggers = append(members, loggers...)
+}
+
+// Remove removes loggers, and their filters, from the list of
+// loggers. It is safe to call while other goroutines are logging.
+func (logs *Loggers) Remove(loggers ...ConLogger) {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+
+	members := make([]ConLogger, 0, len(logs.Loggers))
+	for _, member := range logs.Loggers {
+		if !containsLogger(loggers, member) {
+			members = append(members, member)
+		}
+	}
+	logs.Loggers = members
+	logs.removeFilters(loggers...)
+}
+
+func containsLogger(loggers []ConLogger, logger ConLogger) bool {
+	for _, l := range loggers {
+		if sameLogger(l, logger) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameLogger returns true if a and b are the same logger. Loggers
+// whose type is not comparable, e.g., a struct with a slice used as a
+// value, are compared with reflect.DeepEqual.
+func sameLogger(a ConLogger, b ConLogger) bool {
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	}
+	if t != nil && !t.Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+
+	return a == b
+}
+
+// members returns the current list of loggers. The list is never
+// modified in place so it may be used without holding the lock.
+func (logs *Loggers) members() []ConLogger {
+	logs.mu.RLock()
+	defer logs.mu.RUnlock()
+
+	return logs.Loggers
+}
+
+// SetFilter sets the filter used for messages forwarded to
+// logger. A nil filter removes any existing filter.
+func (logs *Loggers) SetFilter(logger ConLogger, filter *Filter) {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+
+	logs.removeFilters(logger)
+	if filter != nil {
+		logs.filters = append(logs.filters, memberFilter{logger: logger, filter: filter})
+	}
+}
+
+// GetFilter returns the filter set for logger or nil if there is
+// none.
+func (logs *Loggers) GetFilter(logger ConLogger) *Filter {
+	logs.mu.RLock()
+	defer logs.mu.RUnlock()
+
+	return logs.filterFor(logger)
+}
+
+// filterFor returns the filter set for logger or nil. The caller must
+// hold the lock.
+func (logs *Loggers) filterFor(logger ConLogger) *Filter {
+	for _, f := range logs.filters {
+		if sameLogger(f.logger, logger) {
+			return f.filter
+		}
+	}
+
+	return nil
+}
+
+// removeFilters removes the filters set for loggers. The list is
+// replaced rather than modified in place. The caller must hold the
+// lock.
+func (logs *Loggers) removeFilters(loggers ...ConLogger) {
+	filters := make([]memberFilter, 0, len(logs.filters))
+	for _, f := range logs.filters {
+		if !containsLogger(loggers, f.logger) {
+			filters = append(filters, f)
+		}
+	}
+	logs.filters = filters
+}
+
+// SetLevel sets the logger level for all loggers. Loggers with a
+// Filter are never set less verbose than the filter's LevelFloor.
 func (logs *Loggers) SetLevel(level Level) {
-	for _, logger := range logs.Loggers {
+	for _, logger := range logs.members() {
+		if filter := logs.GetFilter(logger); filter != nil {
+			logger.SetLevel(filter.floor(level))
+			continue
+		}
 		logger.SetLevel(level)
 	}
 }
 
+// SetLevelOf sets the logger level of only the given loggers, leaving
+// the others untouched. The LevelFloor of their filters is not
+// applied.
+func (logs *Loggers) SetLevelOf(level Level, loggers ...ConLogger) {
+	for _, logger := range loggers {
+		logger.SetLevel(level)
+	}
+}
+
+// GetLevel returns the most verbose level of all loggers.
+func (logs *Loggers) GetLevel() Level {
+	var level Level = PanicLevel
+	for _, logger := range logs.members() {
+		if l := logger.GetLevel(); l.severity() > level.severity() {
+			level = l
+		}
+	}
+
+	return level
+}
+
 // SetPrintEnabled enables/disables Print*- output for all loggers.
 func (logs *Loggers) SetPrintEnabled(enabled bool) {
-	for _, logger := range logs.Loggers {
+	for _, logger := range logs.members() {
 		logger.SetPrintEnabled(enabled)
 	}
 }
 
+// GetPrintEnabled returns true if Print*-style output is enabled on
+// any of the loggers.
+func (logs *Loggers) GetPrintEnabled() bool {
+	for _, logger := range logs.members() {
+		if logger.GetPrintEnabled() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetVerbosity sets the verbosity used to filter Print*-style output
+// for all loggers.
+func (logs *Loggers) SetVerbosity(verbosity int) {
+	for _, logger := range logs.members() {
+		logger.SetVerbosity(verbosity)
+	}
+}
+
+// GetVerbosity returns the highest verbosity of all loggers.
+func (logs *Loggers) GetVerbosity() int {
+	var verbosity int
+	for i, logger := range logs.members() {
+		if v := logger.GetVerbosity(); i == 0 || v > verbosity {
+			verbosity = v
+		}
+	}
+
+	return verbosity
+}
+
+// GetOutput returns a writer that duplicates its writes to the output
+// of every logger.
+func (logs *Loggers) GetOutput() io.Writer {
+	var writers []io.Writer
+	for _, logger := range logs.members() {
+		writers = append(writers, logger.GetOutput())
+	}
+
+	return multiWriter(writers)
+}
+
+// SetOutput sets the output of all loggers.
+func (logs *Loggers) SetOutput(w io.Writer) {
+	for _, logger := range logs.members() {
+		logger.SetOutput(w)
+	}
+}
+
+// GetErrorOutput returns a writer that duplicates its writes to the
+// error output of every logger.
+func (logs *Loggers) GetErrorOutput() io.Writer {
+	var writers []io.Writer
+	for _, logger := range logs.members() {
+		writers = append(writers, logger.GetErrorOutput())
+	}
+
+	return multiWriter(writers)
+}
+
+// SetErrorOutput sets the error output of all loggers.
+func (logs *Loggers) SetErrorOutput(w io.Writer) {
+	for _, logger := range logs.members() {
+		logger.SetErrorOutput(w)
+	}
+}
+
+// SetFormatter sets the formatter of all loggers.
+func (logs *Loggers) SetFormatter(formatter Formatter) {
+	for _, logger := range logs.members() {
+		logger.SetFormatter(formatter)
+	}
+}
+
+func multiWriter(writers []io.Writer) io.Writer {
+	switch len(writers) {
+	case 0:
+		return ioutil.Discard
+	case 1:
+		return writers[0]
+	}
+
+	return io.MultiWriter(writers...)
+}
+
+// V returns a Verbose whose Print* methods output to the loggers that
+// have printing enabled and a verbosity of at least v. The Include
+// and Exclude lists of a logger's Filter are honored for PrintLevel,
+// but its Predicate is not. Loggers other than *Logger and *Loggers
+// are selected with GetPrintEnabled and GetVerbosity and output to
+// with Print.
+func (logs *Loggers) V(v int) Verbose {
+	var verbose Verbose
+	for _, logger := range logs.members() {
+		if filter := logs.GetFilter(logger); filter != nil && !filter.allowsLevel(PrintLevel) {
+			continue
+		}
+		if l, ok := logger.(verboser); ok {
+			member := l.V(v)
+			verbose.loggers = append(verbose.loggers, member.loggers...)
+			verbose.others = append(verbose.others, member.others...)
+		} else if logger.GetPrintEnabled() && logger.GetVerbosity() >= v {
+			verbose.others = append(verbose.others, logger)
+		}
+	}
+
+	return verbose
+}
+
+// forward calls fn for every logger whose filter accepts a message at
+// level. msg is only called, once, if a filter has a Predicate.
+func (logs *Loggers) forward(level Level, msg func() string, fn func(logger ConLogger)) {
+	logs.mu.RLock()
+	members := logs.Loggers
+	if len(logs.filters) == 0 {
+		logs.mu.RUnlock()
+		for _, logger := range members {
+			fn(logger)
+		}
+		return
+	}
+	targets := make([]ConLogger, 0, len(members))
+	var entry *Entry
+	for _, logger := range members {
+		if filter := logs.filterFor(logger); filter != nil {
+			if !filter.allowsLevel(level) {
+				continue
+			}
+			if filter.Predicate != nil {
+				if entry == nil {
+					entry = &Entry{
+						Time:    time.Now(),
+						Level:   level,
+						Message: msg(),
+					}
+				}
+				if !filter.Predicate(entry) {
+					continue
+				}
+			}
+		}
+		targets = append(targets, logger)
+	}
+	logs.mu.RUnlock()
+
+	for _, logger := range targets {
+		fn(logger)
+	}
+}
+
+// Log logs a message at level on all loggers. Arguments are handled
+// in the manner of fmt.Print.
+func (logs *Loggers) Log(level Level, args ...interface{}) {
+	logs.forward(level, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
+		logTo(logger, level, args...)
+	})
+}
+
+// Logf logs a message at level on all loggers. Arguments are handled
+// in the manner of fmt.Printf.
+func (logs *Loggers) Logf(level Level, format string, args ...interface{}) {
+	logs.forward(level, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
+		logfTo(logger, level, format, args...)
+	})
+}
+
+// Logln logs a message at level on all loggers. Arguments are handled
+// in the manner of fmt.Println.
+func (logs *Loggers) Logln(level Level, args ...interface{}) {
+	logs.forward(level, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
+		loglnTo(logger, level, args...)
+	})
+}
+
+// panicEntry panics with an *Entry holding msg so recovering from
+// Loggers.Panic*() yields the same type as from Logger.Panic*(). The
+// entry belongs to the first member that is a *Logger, or to the
+// standard logger if there is none, so it can be formatted.
+func (logs *Loggers) panicEntry(msg string) {
+	log := std
+	for _, logger := range logs.members() {
+		if l, ok := logger.(*Logger); ok {
+			log = l
+			break
+		}
+	}
+	panic(&Entry{
+		Log:     log,
+		Time:    time.Now(),
+		Level:   PanicLevel,
+		Message: msg,
+	})
+}
+
+// levelLogger is implemented by *Logger and *Loggers.
+type levelLogger interface {
+	Log(level Level, args ...interface{})
+	Logf(level Level, format string, args ...interface{})
+	Logln(level Level, args ...interface{})
+}
+
+// logTo calls Log on logger if it implements levelLogger and
+// otherwise uses logAtSeverity.
+func logTo(logger ConLogger, level Level, args ...interface{}) {
+	if l, ok := logger.(levelLogger); ok {
+		l.Log(level, args...)
+		return
+	}
+	logAtSeverity(logger, level, fmt.Sprint(args...))
+}
+
+// logfTo calls Logf on logger if it implements levelLogger and
+// otherwise uses logAtSeverity.
+func logfTo(logger ConLogger, level Level, format string, args ...interface{}) {
+	if l, ok := logger.(levelLogger); ok {
+		l.Logf(level, format, args...)
+		return
+	}
+	logAtSeverity(logger, level, fmt.Sprintf(format, args...))
+}
+
+// loglnTo calls Logln on logger if it implements levelLogger and
+// otherwise uses logAtSeverity.
+func loglnTo(logger ConLogger, level Level, args ...interface{}) {
+	if l, ok := logger.(levelLogger); ok {
+		l.Logln(level, args...)
+		return
+	}
+	logAtSeverity(logger, level, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+// logAtSeverity logs msg on a logger that does not implement
+// levelLogger using the method for the severity of level. Fatal
+// messages are logged with an exit code of -1 and the panic of Panic
+// messages is recovered, so the caller decides whether to exit or
+// panic, and does so once for all the loggers.
+func logAtSeverity(logger ConLogger, level Level, msg string) {
+	switch level.severity() {
+	case PanicLevel:
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			logger.Panic(msg)
+		}()
+	case FatalLevel:
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					if _, ok := r.(Exit); !ok {
+						panic(r)
+					}
+				}
+			}()
+			logger.FatalWithExitCode(-1, msg)
+		}()
+	case ErrorLevel:
+		logger.Error(msg)
+	case WarnLevel:
+		logger.Warn(msg)
+	case InfoLevel:
+		logger.Info(msg)
+	case DebugLevel:
+		logger.Debug(msg)
+	case TraceLevel:
+		logger.Trace(msg)
+	default:
+		logger.Println(msg)
+	}
+}
+
 // Print print a message to the loggers. It ignores logging levels. No
 // logging levels or timestamps are added. No newline is added. The
 // equivalent of fmt.Fprint.
 func (logs *Loggers) Print(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(PrintLevel, func() string { return fmt.Sprint(args...) }, func(logger ConLogger) {
 		logger.Print(args...)
-	}
+	})
 }
 
 // Printf prints a message to the loggers. It ignores logging
 // levels. No logging levels or timestamps are added. The equivalent
 // of fmt.Fprintf.
 func (logs *Loggers) Printf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(PrintLevel, func() string { return fmt.Sprintf(format, args...) }, func(logger ConLogger) {
 		logger.Printf(format, args...)
-	}
+	})
 }
 
 // Println prints a message to the logger. It ignores logging
 // levels. No logging levels, timestamps, or key files are added. The
 // equivalent of fmt.Fprintln.
 func (logs *Loggers) Println(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(PrintLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
 		logger.Println(args...)
-	}
+	})
+}
+
+// Trace logs a message at level Trace on all loggers. Arguments are
+// handled in the manner of fmt.Print.
+func (logs *Loggers) Trace(args ...interface{}) {
+	logs.forward(TraceLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
+		logger.Trace(args...)
+	})
+}
+
+// Tracef logs a message at level Trace on all loggers. Arguments are
+// handled in the manner of fmt.Printf.
+func (logs *Loggers) Tracef(format string, args ...interface{}) {
+	logs.forward(TraceLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
+		logger.Tracef(format, args...)
+	})
+}
+
+// Traceln logs a message at level Trace on all loggers. Arguments are
+// handled in the manner of fmt.Println.
+func (logs *Loggers) Traceln(args ...interface{}) {
+	logs.forward(TraceLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
+		logger.Traceln(args...)
+	})
 }
 
 // Debug logs a message at level Debug on all loggers. Arguments are
 // handled in the manner of fmt.Print.
 func (logs *Loggers) Debug(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(DebugLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
 		logger.Debug(args...)
-	}
+	})
 }
 
 // Debugf logs a message at level Debug on all loggers. Arguments are
 // handled in the manner of fmt.Printf.
 func (logs *Loggers) Debugf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(DebugLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
 		logger.Debugf(format, args...)
-	}
+	})
 }
 
 // Debugln logs a message at level Debug on all loggers. Arguments are
 // handled in the manner of fmt.Println.
 func (logs *Loggers) Debugln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(DebugLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
 		logger.Debugln(args...)
-	}
+	})
 }
 
 // Info logs a message at level Info on all loggers. Arguments are
 // handled in the manner of fmt.Print.
 func (logs *Loggers) Info(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(InfoLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
 		logger.Info(args...)
-	}
+	})
 }
 
 // Infof logs a message at level Info on all loggers. Arguments are
 // handled in the manner of fmt.Printf.
 func (logs *Loggers) Infof(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(InfoLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
 		logger.Infof(format, args...)
-	}
+	})
 }
 
 // Infoln logs a message at level Info on all loggers. Arguments are
 // handled in the manner of fmt.Println.
 func (logs *Loggers) Infoln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(InfoLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
 		logger.Infoln(args...)
-	}
+	})
 }
 
 // Warn logs a message at level Warn on all loggers. Arguments are
 // handled in the manner of fmt.Print.
 func (logs *Loggers) Warn(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(WarnLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
 		logger.Warn(args...)
-	}
+	})
 }
 
 // Warnf logs a message at level Warn on all loggers. Arguments are
 // handled in the manner of fmt.Printf.
 func (logs *Loggers) Warnf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(WarnLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
 		logger.Warnf(format, args...)
-	}
+	})
 }
 
 // Warnln logs a message at level Warn on all loggers. Arguments are
 // handled in the manner of fmt.Println.
 func (logs *Loggers) Warnln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(WarnLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
 		logger.Warnln(args...)
-	}
+	})
 }
 
 // Warning logs a message at level Warning on all loggers. Arguments
 // are handled in the manner of fmt.Print. Warning is an alias for
 // Warn.
 func (logs *Loggers) Warning(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(WarnLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
 		logger.Warning(args...)
-	}
+	})
 }
 
 // Warningf logs a message at level Warning on all loggers.  Arguments
 // are handled in the manner of fmt.Printf. Warningf is an alias for
 // Warnf.
 func (logs *Loggers) Warningf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(WarnLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
 		logger.Warningf(format, args...)
-	}
+	})
 }
 
 // Warningln logs a message at level Warning on all loggers. Arguments
 // are handled in the manner of fmt.Println. Warningf is an alias for
 // Warnln.
 func (logs *Loggers) Warningln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(WarnLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
 		logger.Warnln(args...)
-	}
+	})
 }
 
 // Error logs a message at level Error on all loggers. Arguments are
 // handled in the manner of fmt.Print.
 func (logs *Loggers) Error(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(ErrorLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
 		logger.Error(args...)
-	}
+	})
 }
 
 // Errorf logs a message at level Error on all loggers. Arguments are
 // handled in the manner of fmt.Printf.
 func (logs *Loggers) Errorf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(ErrorLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
 		logger.Errorf(format, args...)
-	}
+	})
 }
 
 // Errorln logs a message at level Error on all loggers. Arguments are
 // handled in the manner of fmt.Println.
 func (logs *Loggers) Errorln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
+	logs.forward(ErrorLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
 		logger.Errorln(args...)
-	}
+	})
 }
 
-// Fatal displays a message at Fatal level to the first logger and
-// exits with DefaultExitCode error code. Arguments are handled in the
-// manner of fmt.Print.
+// Fatal logs a message at Fatal level on all loggers and then exits
+// with the DefaultExitCode. Arguments are handled in the manner of
+// fmt.Print.
 func (logs *Loggers) Fatal(args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.Fatal(args...)
-	}
+	logs.FatalWithExitCode(DefaultExitCode, args...)
 }
 
-// Fatalf displays a message at Fatal level to the first logger and
-// exits with DefaultExitCode error code. Arguments are handled in the
-// manner of fmt.Printf.
+// Fatalf logs a message at Fatal level on all loggers and then exits
+// with the DefaultExitCode. Arguments are handled in the manner of
+// fmt.Printf.
 func (logs *Loggers) Fatalf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.Fatalf(format, args...)
-	}
+	logs.FatalfWithExitCode(DefaultExitCode, format, args...)
 }
 
-// Fatalln displays a message at Fatal level to the first logger and
-// exits with DefaultExitCode error code.  Arguments are handled in
-// the manner of fmt.Println.
+// Fatalln logs a message at Fatal level on all loggers and then exits
+// with the DefaultExitCode. Arguments are handled in the manner of
+// fmt.Println.
 func (logs *Loggers) Fatalln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.Fatalln(args...)
-	}
+	logs.FatallnWithExitCode(DefaultExitCode, args...)
 }
 
-// FatalWithExitCode displays an message at Fatal level to the first
-// logger and exits with a given exit code.  Arguments are
-// handled in the manner of fmt.Print.
+// FatalWithExitCode logs a message at Fatal level on all loggers. It
+// then exits once with the given exit code if code >= 0. Arguments
+// are handled in the manner of fmt.Print.
 func (logs *Loggers) FatalWithExitCode(code int, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.FatalWithExitCode(code, args...)
-	}
+	logs.fatal(code, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
+		logTo(logger, FatalLevel, args...)
+	})
 }
 
-// FatalfWithExitCode displays an message at Fatal level to the first
-// logger and exits with a given exit code.  Arguments are handled in
-// the manner of fmt.Printf.
+// FatalfWithExitCode logs a message at Fatal level on all loggers. It
+// then exits once with the given exit code if code >= 0. Arguments
+// are handled in the manner of fmt.Printf.
 func (logs *Loggers) FatalfWithExitCode(code int, format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.FatalfWithExitCode(code, format, args...)
-	}
+	logs.fatal(code, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
+		logfTo(logger, FatalLevel, format, args...)
+	})
 }
 
-// FatallnWithExitCode displays an message at Fatal level to the first
-// logger and exits with a given exit code.  Arguments are handled in
-// the manner of fmt.Println.
+// FatallnWithExitCode logs a message at Fatal level on all loggers. It
+// then exits once with the given exit code if code >= 0. Arguments
+// are handled in the manner of fmt.Println.
 func (logs *Loggers) FatallnWithExitCode(code int, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.FatallnWithExitCode(code, args...)
-	}
-}
-
-// FatalIfError displays an message at Fatal level to the first logger
-// and exits with a given exit code if err is not nil. Arguments are
-// handled in the manner of fmt.Print.
+	logs.fatal(code, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
+		loglnTo(logger, FatalLevel, args...)
+	})
+}
+
+// exitCodeLogger is implemented by loggers that record the exit code
+// of a Fatal*() message in its entry.
+type exitCodeLogger interface {
+	logExitCode(code int, msg string)
+}
+
+// fatal logs a Fatal*() message on all loggers and then exits once if
+// code >= 0. Members that record exit codes are passed code and msg,
+// the others are called with fn.
+func (logs *Loggers) fatal(code int, msg func() string, fn func(logger ConLogger)) {
+	logs.forward(FatalLevel, msg, func(logger ConLogger) {
+		if l, ok := logger.(exitCodeLogger); ok && code >= 0 {
+			l.logExitCode(code, msg())
+			return
+		}
+		fn(logger)
+	})
+	if code >= 0 {
+		markForExit(logs)
+		panic(Exit{code})
+	}
+}
+
+// logExitCode logs msg at Fatal level on all loggers without exiting,
+// passing code to the members that record it.
+func (logs *Loggers) logExitCode(code int, msg string) {
+	logs.forward(FatalLevel, func() string { return msg }, func(logger ConLogger) {
+		if l, ok := logger.(exitCodeLogger); ok {
+			l.logExitCode(code, msg)
+			return
+		}
+		logAtSeverity(logger, FatalLevel, strings.TrimSuffix(msg, "\n"))
+	})
+}
+
+// FatalIfError logs a message at Fatal level on all loggers if err is
+// not nil. It then exits once with the given exit code (again if err
+// is not nil) if code >= 0. Arguments are handled in the manner of
+// fmt.Print.
 func (logs *Loggers) FatalIfError(err error, code int, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.FatalIfError(err, code, args...)
+	if err == nil {
+		return
 	}
+	logs.FatalWithExitCode(code, args...)
 }
 
-// FatalfIfError displays a message at Fatal level to the first logger
-// and exits with a given exit code and if err is not nil.  Arguments
-// are handled in the manner of fmt.Printf.
+// FatalfIfError logs a message at Fatal level on all loggers if err
+// is not nil. It then exits once with the given exit code (again if
+// err is not nil) if code >= 0. Arguments are handled in the manner
+// of fmt.Printf.
 func (logs *Loggers) FatalfIfError(err error, code int, format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.FatalfIfError(err, code, format, args...)
+	if err == nil {
+		return
 	}
+	logs.FatalfWithExitCode(code, format, args...)
 }
 
-// FatallnIfError displays a message at Fatal level to the first
-// logger and exits with a given exit code and if err is not nil.
-// Arguments are handled in the manner of fmt.Printf.
+// FatallnIfError logs a message at Fatal level on all loggers if err
+// is not nil. It then exits once with the given exit code (again if
+// err is not nil) if code >= 0. Arguments are handled in the manner
+// of fmt.Println.
 func (logs *Loggers) FatallnIfError(err error, code int, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.FatallnIfError(err, code, args...)
+	if err == nil {
+		return
 	}
+	logs.FatallnWithExitCode(code, args...)
 }
 
-// Panic displays a message to the logger at Panic level and then
-// panics. Arguments are handled in the manner of fmt.Print.
+// Panic logs a message at Panic level on all loggers and then panics
+// once with an *Entry holding the message. Arguments are handled in
+// the manner of fmt.Print.
 func (logs *Loggers) Panic(args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.Panic(args...)
-	}
+	logs.forward(PanicLevel, func() string { return fmt.Sprint(args...) + "\n" }, func(logger ConLogger) {
+		logTo(logger, PanicLevel, args...)
+	})
+	logs.panicEntry(fmt.Sprint(args...) + "\n")
 }
 
-// Panicf displays a message to the logger at Panic level and then
-// panics. Arguments are handled in the manner of fmt.Printf.
+// Panicf logs a message at Panic level on all loggers and then panics
+// once with an *Entry holding the message. Arguments are handled in
+// the manner of fmt.Printf.
 func (logs *Loggers) Panicf(format string, args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.Panicf(format, args...)
-	}
+	logs.forward(PanicLevel, func() string { return fmt.Sprintf(format, args...) + "\n" }, func(logger ConLogger) {
+		logfTo(logger, PanicLevel, format, args...)
+	})
+	logs.panicEntry(fmt.Sprintf(format, args...) + "\n")
 }
 
-// Panicln displays a message to the logger at Panic level and then
-// panics. Arguments are handled in the manner of fmt.Println.
+// Panicln logs a message at Panic level on all loggers and then
+// panics once with an *Entry holding the message. Arguments are
+// handled in the manner of fmt.Println.
 func (logs *Loggers) Panicln(args ...interface{}) {
-	for _, logger := range logs.Loggers {
-		logger.Panicln(args...)
-	}
+	logs.forward(PanicLevel, func() string { return fmt.Sprintln(args...) }, func(logger ConLogger) {
+		loglnTo(logger, PanicLevel, args...)
+	})
+	logs.panicEntry(fmt.Sprintln(args...))
 }
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/apatters/go-conlog"
@@ -45,6 +46,8 @@ func TestLoggersPrintStyle(t *testing.T) {
 
 	loggers := conlog.NewLoggers(loggersList...)
 	var tests = []testPrint{
+		{"Trace", loggers.Trace, conlog.TraceLevel},
+		{"Traceln", loggers.Traceln, conlog.TraceLevel},
 		{"Debug", loggers.Debug, conlog.DebugLevel},
 		{"Debugln", loggers.Debugln, conlog.DebugLevel},
 		{"Info", loggers.Info, conlog.InfoLevel},
@@ -154,3 +157,275 @@ func TestLoggers_Panic(t *testing.T) {
 		assert.Equal(t, cmpStr, outs[i].String())
 	}
 }
+
+func TestLoggers_Filter(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.TraceLevel)
+	loggers := conlog.NewLoggers(loggerList...)
+
+	filter := conlog.NewFilter()
+	filter.Exclude = []conlog.Level{conlog.WarnLevel}
+	filter.Predicate = func(entry *conlog.Entry) bool {
+		return !strings.Contains(entry.Message, "secret")
+	}
+	loggers.SetFilter(loggerList[1], filter)
+	assert.Equal(t, filter, loggers.GetFilter(loggerList[1]))
+	assert.Nil(t, loggers.GetFilter(loggerList[0]))
+
+	loggers.Info("Info test")
+	loggers.Warn("Warn test")
+	loggers.Errorf("Error %s test", "secret")
+
+	var tests = []struct {
+		out *bytes.Buffer
+		cmp string
+	}{
+		{outs[0], "INFO Info test\nWARN Warn test\nERRO Error secret test\n"},
+		{outs[1], "INFO Info test\n"},
+	}
+	for i, test := range tests {
+		t.Logf("outs[%d] = %q", i, test.out.String())
+		t.Logf("cmpStr   = %q", test.cmp)
+		assert.Equal(t, test.cmp, test.out.String())
+		test.out.Reset()
+	}
+
+	loggers.SetFilter(loggerList[1], &conlog.Filter{Include: []conlog.Level{conlog.ErrorLevel}})
+	loggers.Info("Info test")
+	loggers.Println("Print test")
+	loggers.V(0).Println("Print test")
+	loggers.Error("Error test")
+	cmpStr := "ERRO Error test\n"
+	t.Logf("outs[1] = %q", outs[1].String())
+	t.Logf("cmpStr  = %q", cmpStr)
+	assert.Equal(t, cmpStr, outs[1].String())
+
+	loggers.SetFilter(loggerList[1], nil)
+	assert.Nil(t, loggers.GetFilter(loggerList[1]))
+}
+
+// taggedLogger is a ConLogger whose type is not comparable.
+type taggedLogger struct {
+	conlog.ConLogger
+	tags []string
+}
+
+func TestLoggers_FilterNotComparable(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	member := taggedLogger{loggerList[1], []string{"audit"}}
+	loggers := conlog.NewLoggers(loggerList[0], member)
+
+	filter := &conlog.Filter{Include: []conlog.Level{conlog.ErrorLevel}}
+	loggers.SetFilter(member, filter)
+	assert.Equal(t, filter, loggers.GetFilter(member))
+	loggers.Info("Info test")
+	loggers.Error("Error test")
+	assert.Equal(t, "INFO Info test\nERRO Error test\n", outs[0].String())
+	assert.Equal(t, "ERRO Error test\n", outs[1].String())
+
+	loggers.Remove(member)
+	assert.Nil(t, loggers.GetFilter(member))
+	assert.Len(t, loggers.Loggers, 1)
+}
+
+func TestLoggers_LevelFloor(t *testing.T) {
+	loggerList, _ := newSimpleLoggers(conlog.InfoLevel)
+	loggers := conlog.NewLoggers(loggerList...)
+
+	loggers.SetFilter(loggerList[1], &conlog.Filter{LevelFloor: conlog.DebugLevel})
+	loggers.SetLevel(conlog.WarnLevel)
+	assert.Equal(t, conlog.Level(conlog.WarnLevel), loggerList[0].GetLevel())
+	assert.Equal(t, conlog.Level(conlog.DebugLevel), loggerList[1].GetLevel())
+
+	loggers.SetLevel(conlog.TraceLevel)
+	assert.Equal(t, conlog.Level(conlog.TraceLevel), loggerList[0].GetLevel())
+	assert.Equal(t, conlog.Level(conlog.TraceLevel), loggerList[1].GetLevel())
+
+	loggers.SetLevelOf(conlog.ErrorLevel, loggerList[1])
+	assert.Equal(t, conlog.Level(conlog.TraceLevel), loggerList[0].GetLevel())
+	assert.Equal(t, conlog.Level(conlog.ErrorLevel), loggerList[1].GetLevel())
+}
+
+func TestLoggers_FatalAllMembers(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	// A member that is not a *Logger still logs at Fatal level
+	// without exiting on its own.
+	loggers := conlog.NewLoggers(plainLogger{loggerList[0]}, loggerList[1])
+
+	var tests = []struct {
+		FnName string
+		Fn     func()
+		Code   int
+		CmpStr string
+	}{
+		{"Fatal", func() { loggers.Fatal("Fatal test") }, conlog.DefaultExitCode, "FATA Fatal test\n"},
+		{"Fatalf", func() { loggers.Fatalf("%s test", "Fatalf") }, conlog.DefaultExitCode, "FATA Fatalf test\n"},
+		{"Fatalln", func() { loggers.Fatalln("Fatalln test") }, conlog.DefaultExitCode, "FATA Fatalln test\n"},
+		{"FatalWithExitCode", func() { loggers.FatalWithExitCode(3, "FatalWithExitCode test") }, 3, "FATA FatalWithExitCode test\n"},
+		{"FatalfIfError", func() { loggers.FatalfIfError(fmt.Errorf("error"), 4, "%s test", "FatalfIfError") }, 4, "FATA FatalfIfError test\n"},
+	}
+
+	for _, test := range tests {
+		var exits int
+		func() {
+			defer func() {
+				r := recover()
+				exit, ok := r.(conlog.Exit)
+				if assert.True(t, ok, "%s: expected Exit, got %v", test.FnName, r) {
+					exits++
+					assert.Equal(t, test.Code, exit.Code)
+				}
+			}()
+			test.Fn()
+		}()
+		assert.Equal(t, 1, exits)
+		for i, out := range outs {
+			t.Logf("func: %s", test.FnName)
+			t.Logf("outs[%d] = %q", i, out.String())
+			t.Logf("cmpStr   = %q", test.CmpStr)
+			assert.Equal(t, test.CmpStr, out.String())
+			out.Reset()
+		}
+	}
+
+	loggers.FatalIfError(nil, 1, "FatalIfError test")
+	loggers.FatalWithExitCode(-1, "FatalWithExitCode test")
+	for _, out := range outs {
+		assert.Equal(t, "FATA FatalWithExitCode test\n", out.String())
+	}
+}
+
+func TestLoggers_PanicAllMembers(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	// A member that is not a *Logger still logs at Panic level,
+	// and the Loggers panics once.
+	loggers := conlog.NewLoggers(plainLogger{loggerList[0]}, loggerList[1])
+
+	defer func() {
+		r := recover()
+		entry, ok := r.(*conlog.Entry)
+		if assert.True(t, ok) {
+			assert.Equal(t, conlog.Level(conlog.PanicLevel), entry.Level)
+			assert.Equal(t, "Panic test\n", entry.Message)
+			str, err := entry.String()
+			assert.NoError(t, err)
+			assert.Equal(t, "PANI Panic test\n", str)
+		}
+		cmpStr := "PANI Panic test\n"
+		for i, out := range outs {
+			t.Logf("outs[%d] = %q", i, out.String())
+			t.Logf("cmpStr   = %q", cmpStr)
+			assert.Equal(t, cmpStr, out.String())
+		}
+	}()
+	loggers.Panic("Panic test")
+}
+
+func TestLoggers_ConLogger(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	loggerList[1].SetLevel(conlog.DebugLevel)
+	loggerList[1].SetPrintEnabled(false)
+
+	var logger conlog.ConLogger = conlog.NewLoggers(loggerList...)
+	assert.Equal(t, conlog.Level(conlog.DebugLevel), logger.GetLevel())
+	assert.True(t, logger.GetPrintEnabled())
+	logger.SetPrintEnabled(false)
+	assert.False(t, logger.GetPrintEnabled())
+
+	fmt.Fprint(logger.GetOutput(), "out\n")
+	fmt.Fprint(logger.GetErrorOutput(), "err\n")
+	for i, out := range outs {
+		t.Logf("outs[%d] = %q", i, out.String())
+		assert.Equal(t, "out\nerr\n", out.String())
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetErrorOutput(&buf)
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.LogLevelFmt = conlog.LogLevelFormatLongTitle
+	logger.SetFormatter(formatter)
+	logger.Info("Info test")
+	logger.Error("Error test")
+	cmpStr := "Info Info test\nInfo Info test\nError Error test\nError Error test\n"
+	t.Logf("out string = %q", buf.String())
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, buf.String())
+}
+
+func TestLoggers_Nested(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	inner := conlog.NewLoggers(loggerList[1])
+	outer := conlog.NewLoggers(loggerList[0], inner)
+
+	outer.Info("Info test")
+	func() {
+		defer func() {
+			r := recover()
+			assert.Equal(t, conlog.Exit{Code: 2}, r)
+		}()
+		outer.FatalWithExitCode(2, "Fatal test")
+	}()
+
+	cmpStr := "INFO Info test\nFATA Fatal test\n"
+	for i, out := range outs {
+		t.Logf("outs[%d] = %q", i, out.String())
+		t.Logf("cmpStr   = %q", cmpStr)
+		assert.Equal(t, cmpStr, out.String())
+	}
+}
+
+func TestLoggers_AddRemove(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	loggers := conlog.NewLoggers(loggerList[0])
+
+	// The first logger gets every message while the second is added
+	// and removed until the writers finish, so it gets some whole
+	// messages.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			loggers.Add(loggerList[1])
+			loggers.Remove(loggerList[1])
+		}
+	}()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				loggers.Info("Concurrent test")
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-done
+	cmp := strings.Repeat("INFO Concurrent test\n", 400)
+	assert.Equal(t, cmp, outs[0].String())
+	for _, line := range strings.SplitAfter(outs[1].String(), "\n") {
+		if line != "" {
+			assert.Equal(t, "INFO Concurrent test\n", line)
+		}
+	}
+	t.Logf("out[1] = %d lines", strings.Count(outs[1].String(), "\n"))
+	outs[0].Reset()
+	outs[1].Reset()
+
+	loggers.Add(loggerList[1])
+	loggers.SetFilter(loggerList[1], &conlog.Filter{Exclude: []conlog.Level{conlog.WarnLevel}})
+	loggers.Remove(loggerList[1])
+	assert.Nil(t, loggers.GetFilter(loggerList[1]))
+	assert.Equal(t, []conlog.ConLogger{loggerList[0]}, loggers.Loggers)
+
+	loggers.Info("Info test")
+	assert.Equal(t, "INFO Info test\n", outs[0].String())
+	assert.Equal(t, "", outs[1].String())
+}
@@ -0,0 +1,384 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LokiEncoding selects how batches are encoded for the Loki push API.
+type LokiEncoding int
+
+const (
+	// LokiEncodingUnknown is an unknown encoding.
+	LokiEncodingUnknown LokiEncoding = iota
+
+	// LokiEncodingJSON sends batches as JSON.
+	LokiEncodingJSON
+
+	// LokiEncodingProtobuf sends batches as snappy-compressed
+	// protocol buffers, the format Loki handles most efficiently.
+	LokiEncodingProtobuf
+)
+
+// DefaultLokiLevelLabel is the default label holding the entry level.
+const DefaultLokiLevelLabel = "level"
+
+// LokiOptions configure a LokiSink.
+type LokiOptions struct {
+	// URL is the push endpoint, e.g.,
+	// "http://loki:3100/loki/api/v1/push".
+	URL string
+
+	// Labels are static stream labels added to every entry,
+	// e.g., {"job": "myapp"}.
+	Labels map[string]string
+
+	// LabelFields are entry fields that are promoted to stream
+	// labels. Keep these to fields with few distinct values.
+	LabelFields []string
+
+	// LevelLabel is the label holding the entry level. An empty
+	// string disables it. The default is DefaultLokiLevelLabel.
+	LevelLabel string
+
+	// Encoding is the request encoding. The default is
+	// LokiEncodingProtobuf.
+	Encoding LokiEncoding
+
+	// Formatter renders the log line. The default is a
+	// LogfmtFormatter without a timestamp as Loki records the
+	// entry time itself.
+	Formatter Formatter
+
+	// Levels are the levels sent to Loki. The default is every
+	// level returned by GetAllLevels.
+	Levels []Level
+
+	// TenantID, if set, is sent in the X-Scope-OrgID header.
+	TenantID string
+
+	// BatchSize, BatchInterval, and QueueSize control batching.
+	// Entries arriving when the queue is full are dropped and
+	// counted. The defaults are DefaultBatchSize,
+	// DefaultBatchInterval, and DefaultQueueSize.
+	BatchSize     int
+	BatchInterval time.Duration
+	QueueSize     int
+
+	// MaxRetries, MinBackoff, and MaxBackoff control retrying
+	// failed pushes. The defaults are DefaultMaxRetries,
+	// DefaultMinBackoff, and DefaultMaxBackoff.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Client is the HTTP client used for pushes. The default has
+	// a 10 second timeout.
+	Client *http.Client
+
+	// ErrorHandler is called when a batch cannot be pushed. The
+	// default writes the error to stderr.
+	ErrorHandler func(err error)
+}
+
+// NewLokiOptions is the constructor for LokiOptions.
+func NewLokiOptions(url string) *LokiOptions {
+	formatter := NewLogfmtFormatter()
+	formatter.TimestampType = TimestampTypeNone
+
+	return &LokiOptions{
+		URL:           url,
+		LevelLabel:    DefaultLokiLevelLabel,
+		Encoding:      LokiEncodingProtobuf,
+		Formatter:     formatter,
+		BatchSize:     DefaultBatchSize,
+		BatchInterval: DefaultBatchInterval,
+		QueueSize:     DefaultQueueSize,
+		MaxRetries:    DefaultMaxRetries,
+		MinBackoff:    DefaultMinBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// LokiSink is a Hook that batches entries and pushes them to Grafana
+// Loki. Add it to a logger with AddHook:
+//
+//	sink, err := conlog.NewLokiSink(conlog.NewLokiOptions(url))
+//	log.AddHook(sink)
+//	defer sink.Close()
+//
+// Entries are pushed in the background. Until it is closed, the sink
+// is flushed by HandleExit so the last entries, including Fatal*()
+// messages, reach Loki.
+type LokiSink struct {
+	options LokiOptions
+	labels  map[string]string
+	batcher *batcher
+	retrier retrier
+}
+
+var _ Hook = (*LokiSink)(nil)
+
+// lokiEntry is a queued entry.
+type lokiEntry struct {
+	labels    map[string]string
+	labelsKey string
+	time      time.Time
+	line      string
+}
+
+// NewLokiSink is the constructor for LokiSink. A nil options is an
+// error as the URL is required.
+func NewLokiSink(options *LokiOptions) (*LokiSink, error) {
+	if options == nil || options.URL == "" {
+		return nil, fmt.Errorf("Loki URL is required")
+	}
+	s := &LokiSink{
+		options: *options,
+		labels:  make(map[string]string, len(options.Labels)),
+	}
+	for k, v := range options.Labels {
+		s.labels[lokiLabelName(k)] = v
+	}
+	if s.options.Formatter == nil {
+		s.options.Formatter = NewLokiOptions("").Formatter
+	}
+	if s.options.Levels == nil {
+		s.options.Levels = GetAllLevels()
+	}
+	if s.options.Client == nil {
+		s.options.Client = http.DefaultClient
+	}
+	s.retrier = newRetrier(s.options.MaxRetries, s.options.MinBackoff, s.options.MaxBackoff)
+	s.batcher = newBatcher(s.options.QueueSize, s.options.BatchSize, s.options.BatchInterval, s.push)
+	registerHook(s)
+
+	return s, nil
+}
+
+// Levels returns the levels the sink is fired for.
+func (s *LokiSink) Levels() []Level {
+	return s.options.Levels
+}
+
+// Fire formats the entry and queues it under its stream labels for
+// the next push. If Loki falls behind and the queue fills up, the
+// entry is dropped and counted by Dropped.
+func (s *LokiSink) Fire(entry *Entry) error {
+	e := *entry
+	e.Buffer = nil
+	line, err := s.options.Formatter.Format(&e)
+	if err != nil {
+		return err
+	}
+	if len(line) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(s.labels)+len(s.options.LabelFields)+1)
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	for _, field := range s.options.LabelFields {
+		if v, ok := entry.Fields[field]; ok {
+			labels[lokiLabelName(field)] = fmt.Sprint(v)
+		}
+	}
+	if s.options.LevelLabel != "" {
+		labels[lokiLabelName(s.options.LevelLabel)] = entry.Level.String()
+	}
+	s.batcher.add(lokiEntry{
+		labels:    labels,
+		labelsKey: lokiLabels(labels),
+		time:      entry.Time,
+		line:      strings.TrimSuffix(string(line), "\n"),
+	})
+
+	return nil
+}
+
+// Flush pushes all queued entries and waits for the push to finish.
+func (s *LokiSink) Flush() {
+	s.batcher.flush()
+}
+
+// Close pushes all queued entries and stops the sink. Entries fired
+// after Close are dropped.
+func (s *LokiSink) Close() error {
+	unregisterHook(s)
+	s.batcher.close()
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was
+// full or the sink was closed.
+func (s *LokiSink) Dropped() uint64 {
+	return s.batcher.droppedCount()
+}
+
+// push sends a batch to Loki. It is called by the batcher.
+func (s *LokiSink) push(batch []interface{}) {
+	streams := make(map[string][]lokiEntry)
+	var keys []string
+	for _, item := range batch {
+		e := item.(lokiEntry)
+		if _, ok := streams[e.labelsKey]; !ok {
+			keys = append(keys, e.labelsKey)
+		}
+		streams[e.labelsKey] = append(streams[e.labelsKey], e)
+	}
+
+	header := make(http.Header)
+	var body []byte
+	var err error
+	if s.options.Encoding == LokiEncodingJSON {
+		header.Set("Content-Type", "application/json")
+		body, err = lokiJSON(keys, streams)
+	} else {
+		header.Set("Content-Type", "application/x-protobuf")
+		body = snappyEncode(lokiProtobuf(keys, streams))
+	}
+	if s.options.TenantID != "" {
+		header.Set("X-Scope-OrgID", s.options.TenantID)
+	}
+	if err == nil {
+		err = s.retrier.do(func() error {
+			return postBatch(s.options.Client, s.options.URL, header, body)
+		})
+	}
+	if err != nil {
+		handleError(s.options.ErrorHandler, fmt.Errorf("failed to push %d entries to Loki, %v", len(batch), err))
+	}
+}
+
+// lokiLabels renders labels in the Prometheus label syntax Loki uses
+// to identify streams, e.g., {job="myapp", level="info"}.
+func lokiLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// lokiLabelName replaces the characters not allowed in a label name
+// with underscores.
+func lokiLabelName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	return name
+}
+
+func lokiJSON(keys []string, streams map[string][]lokiEntry) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	var request struct {
+		Streams []stream `json:"streams"`
+	}
+	for _, key := range keys {
+		s := stream{Stream: streams[key][0].labels}
+		for _, e := range streams[key] {
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+		}
+		request.Streams = append(request.Streams, s)
+	}
+
+	return json.Marshal(request)
+}
+
+// lokiProtobuf encodes a logproto.PushRequest:
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter {
+//	    string labels = 1;
+//	    repeated EntryAdapter entries = 2;
+//	}
+//	message EntryAdapter {
+//	    google.protobuf.Timestamp timestamp = 1;
+//	    string line = 2;
+//	}
+//	message Timestamp { int64 seconds = 1; int32 nanos = 2; }
+func lokiProtobuf(keys []string, streams map[string][]lokiEntry) []byte {
+	var request []byte
+	for _, key := range keys {
+		var stream []byte
+		stream = protoAppendBytes(stream, 1, []byte(key))
+		for _, e := range streams[key] {
+			var timestamp []byte
+			timestamp = protoAppendVarint(timestamp, 1, uint64(e.time.Unix()))
+			timestamp = protoAppendVarint(timestamp, 2, uint64(e.time.Nanosecond()))
+			var entry []byte
+			entry = protoAppendBytes(entry, 1, timestamp)
+			entry = protoAppendBytes(entry, 2, []byte(e.line))
+			stream = protoAppendBytes(stream, 2, entry)
+		}
+		request = protoAppendBytes(request, 1, stream)
+	}
+
+	return request
+}
+
+// protoAppendVarint appends a varint field. Zero values are omitted
+// as in proto3.
+func protoAppendVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protoAppendKey(b, field, 0)
+
+	return protoAppendUvarint(b, v)
+}
+
+// protoAppendBytes appends a length-delimited field.
+func protoAppendBytes(b []byte, field int, v []byte) []byte {
+	b = protoAppendKey(b, field, 2)
+	b = protoAppendUvarint(b, uint64(len(v)))
+
+	return append(b, v...)
+}
+
+func protoAppendKey(b []byte, field int, wireType int) []byte {
+	return protoAppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func protoAppendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,321 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// lokiStream is a decoded stream from a push request.
+type lokiStream struct {
+	Labels string
+	Lines  []string
+}
+
+// lokiServer records the streams pushed to it.
+type lokiServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	streams  []lokiStream
+	requests int32
+	failures int32
+	headers  http.Header
+}
+
+func newLokiServer(t *testing.T, failures int32) *lokiServer {
+	s := &lokiServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&s.requests, 1) <= atomic.LoadInt32(&s.failures) {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var streams []lokiStream
+		var err error
+		if r.Header.Get("Content-Type") == "application/json" {
+			streams, err = decodeLokiJSON(body)
+		} else {
+			streams, err = decodeLokiProtobuf(body)
+		}
+		if err != nil {
+			t.Errorf("bad push request: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.streams = append(s.streams, streams...)
+		s.headers = r.Header
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return s
+}
+
+func (s *lokiServer) lines() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make(map[string][]string)
+	for _, stream := range s.streams {
+		lines[stream.Labels] = append(lines[stream.Labels], stream.Lines...)
+	}
+
+	return lines
+}
+
+func decodeLokiJSON(body []byte) ([]lokiStream, error) {
+	var request struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	var streams []lokiStream
+	for _, s := range request.Streams {
+		var labels []string
+		for _, k := range []string{"job", "level", "user"} {
+			if v, ok := s.Stream[k]; ok {
+				labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+			}
+		}
+		stream := lokiStream{Labels: "{" + strings.Join(labels, ", ") + "}"}
+		for _, v := range s.Values {
+			stream.Lines = append(stream.Lines, v[1])
+		}
+		streams = append(streams, stream)
+	}
+
+	return streams, nil
+}
+
+// decodeSnappy decodes a snappy block.
+func decodeSnappy(src []byte) ([]byte, error) {
+	n, i := binary.Uvarint(src)
+	if i <= 0 {
+		return nil, fmt.Errorf("bad snappy length")
+	}
+	dst := make([]byte, 0, n)
+	for i < len(src) {
+		tag := src[i]
+		var length, offset int
+		switch tag & 0x03 {
+		case 0x00:
+			length = int(tag>>2) + 1
+			i++
+			if extra := int(tag>>2) - 59; extra > 0 {
+				length = 0
+				for j := 0; j < extra; j++ {
+					length |= int(src[i+j]) << (8 * uint(j))
+				}
+				length++
+				i += extra
+			}
+			dst = append(dst, src[i:i+length]...)
+			i += length
+			continue
+		case 0x01:
+			length = int(tag>>2&0x07) + 4
+			offset = int(tag>>5)<<8 | int(src[i+1])
+			i += 2
+		case 0x02:
+			length = int(tag>>2) + 1
+			offset = int(src[i+1]) | int(src[i+2])<<8
+			i += 3
+		default:
+			return nil, fmt.Errorf("unsupported snappy tag %#x", tag)
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, fmt.Errorf("bad snappy offset %d", offset)
+		}
+		for j := 0; j < length; j++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if uint64(len(dst)) != n {
+		return nil, fmt.Errorf("snappy length %d, want %d", len(dst), n)
+	}
+
+	return dst, nil
+}
+
+// protoFields returns the length-delimited fields of a protobuf
+// message, skipping varints.
+func protoFields(b []byte) (map[uint64][][]byte, error) {
+	fields := make(map[uint64][][]byte)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+		switch key & 0x07 {
+		case 0:
+			_, n = binary.Uvarint(b)
+			b = b[n:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			b = b[n:]
+			fields[key>>3] = append(fields[key>>3], b[:length])
+			b = b[length:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", key&0x07)
+		}
+	}
+
+	return fields, nil
+}
+
+func decodeLokiProtobuf(body []byte) ([]lokiStream, error) {
+	request, err := decodeSnappy(body)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := protoFields(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []lokiStream
+	for _, s := range fields[1] {
+		streamFields, err := protoFields(s)
+		if err != nil {
+			return nil, err
+		}
+		stream := lokiStream{Labels: string(streamFields[1][0])}
+		for _, e := range streamFields[2] {
+			entryFields, err := protoFields(e)
+			if err != nil {
+				return nil, err
+			}
+			stream.Lines = append(stream.Lines, string(entryFields[2][0]))
+		}
+		streams = append(streams, stream)
+	}
+
+	return streams, nil
+}
+
+func newLokiLogger(t *testing.T, options *conlog.LokiOptions) (*conlog.Logger, *conlog.LokiSink) {
+	sink, err := conlog.NewLokiSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newHookLogger(sink), sink
+}
+
+func TestLokiSink_Encodings(t *testing.T) {
+	for _, encoding := range []conlog.LokiEncoding{conlog.LokiEncodingJSON, conlog.LokiEncodingProtobuf} {
+		server := newLokiServer(t, 0)
+		options := conlog.NewLokiOptions(server.URL)
+		options.Encoding = encoding
+		options.Labels = map[string]string{"job": "test"}
+		options.LabelFields = []string{"user"}
+		options.TenantID = "tenant"
+		log, sink := newLokiLogger(t, options)
+
+		log.Info("Info test")
+		log.WithField("user", "bob").Warn("Warn test")
+		log.Info(strings.Repeat("Repeated test ", 20))
+		sink.Flush()
+
+		cmp := map[string][]string{
+			`{job="test", level="info"}`: {
+				`level=info msg="Info test"`,
+				fmt.Sprintf("level=info msg=%q", strings.Repeat("Repeated test ", 20)),
+			},
+			`{job="test", level="warning", user="bob"}`: {`level=warning msg="Warn test" user=bob`},
+		}
+		t.Logf("encoding = %d, lines = %q", encoding, server.lines())
+		assert.Equal(t, cmp, server.lines())
+		assert.Equal(t, "tenant", server.headers.Get("X-Scope-OrgID"))
+		assert.NoError(t, sink.Close())
+		server.Close()
+	}
+}
+
+func TestLokiSink_Retry(t *testing.T) {
+	server := newLokiServer(t, 2)
+	defer server.Close()
+	options := conlog.NewLokiOptions(server.URL)
+	options.MinBackoff = time.Millisecond
+	log, sink := newLokiLogger(t, options)
+	defer sink.Close()
+
+	log.Error("Error test")
+	sink.Flush()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.requests))
+	assert.Equal(t, []string{`level=error msg="Error test"`}, server.lines()[`{level="error"}`])
+}
+
+func TestLokiSink_Dropped(t *testing.T) {
+	options := conlog.NewLokiOptions("http://127.0.0.1:1/loki/api/v1/push")
+	log, sink := newLokiLogger(t, options)
+
+	assert.NoError(t, sink.Close())
+	log.Info("Dropped test")
+	assert.Equal(t, uint64(1), sink.Dropped())
+}
+
+func TestLokiSink_HandleExit(t *testing.T) {
+	server := newLokiServer(t, 0)
+	defer server.Close()
+	options := conlog.NewLokiOptions(server.URL)
+	options.BatchInterval = time.Hour
+	log, sink := newLokiLogger(t, options)
+	defer sink.Close()
+
+	func() {
+		defer func() {
+			assert.Equal(t, "Panic test", recover())
+		}()
+		defer conlog.HandleExit()
+		log.Error("Error test")
+		panic("Panic test")
+	}()
+	assert.Equal(t, []string{`level=error msg="Error test"`}, server.lines()[`{level="error"}`])
+}
+
+func TestLokiSink_CloseWhileLogging(t *testing.T) {
+	server := newLokiServer(t, 0)
+	defer server.Close()
+	options := conlog.NewLokiOptions(server.URL)
+	options.QueueSize = 10000
+	log, sink := newLokiLogger(t, options)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				log.Info("Close test")
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, sink.Close())
+	wg.Wait()
+
+	// Every entry is either pushed or counted as dropped.
+	sent := len(server.lines()[`{level="info"}`])
+	t.Logf("sent = %d, dropped = %d", sent, sink.Dropped())
+	assert.Equal(t, uint64(2000), uint64(sent)+sink.Dropped())
+}
@@ -0,0 +1,350 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// A minimal MessagePack (https://msgpack.org) encoder for the Fluent
+// forward protocol and a decoder for the acknowledgements it returns.
+
+// msgpackAppend appends the MessagePack encoding of v. Types without
+// a MessagePack equivalent are encoded as their fmt.Sprint string.
+func msgpackAppend(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return msgpackAppendInt(b, int64(v))
+	case int8:
+		return msgpackAppendInt(b, int64(v))
+	case int16:
+		return msgpackAppendInt(b, int64(v))
+	case int32:
+		return msgpackAppendInt(b, int64(v))
+	case int64:
+		return msgpackAppendInt(b, v)
+	case uint:
+		return msgpackAppendUint(b, uint64(v))
+	case uint8:
+		return msgpackAppendUint(b, uint64(v))
+	case uint16:
+		return msgpackAppendUint(b, uint64(v))
+	case uint32:
+		return msgpackAppendUint(b, uint64(v))
+	case uint64:
+		return msgpackAppendUint(b, v)
+	case float32:
+		b = append(b, 0xca)
+		return appendUint32(b, math.Float32bits(v))
+	case float64:
+		b = append(b, 0xcb)
+		return appendUint64(b, math.Float64bits(v))
+	case string:
+		return msgpackAppendString(b, v)
+	case []byte:
+		return msgpackAppendBinary(b, v)
+	case error:
+		return msgpackAppendString(b, v.Error())
+	case time.Time:
+		return msgpackAppendString(b, v.Format(time.RFC3339Nano))
+	case []interface{}:
+		b = msgpackAppendArrayHeader(b, len(v))
+		for _, e := range v {
+			b = msgpackAppend(b, e)
+		}
+		return b
+	case Fields:
+		return msgpackAppendMap(b, v)
+	case map[string]interface{}:
+		return msgpackAppendMap(b, v)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = e
+		}
+		return msgpackAppendMap(b, m)
+	default:
+		return msgpackAppendString(b, fmt.Sprint(v))
+	}
+}
+
+func msgpackAppendInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return msgpackAppendUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return append(b, 0xd1, byte(v>>8), byte(v))
+	case v >= math.MinInt32:
+		b = append(b, 0xd2)
+		return appendUint32(b, uint32(v))
+	default:
+		b = append(b, 0xd3)
+		return appendUint64(b, uint64(v))
+	}
+}
+
+func msgpackAppendUint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<7:
+		return append(b, byte(v))
+	case v < 1<<8:
+		return append(b, 0xcc, byte(v))
+	case v < 1<<16:
+		return append(b, 0xcd, byte(v>>8), byte(v))
+	case v < 1<<32:
+		b = append(b, 0xce)
+		return appendUint32(b, uint32(v))
+	default:
+		b = append(b, 0xcf)
+		return appendUint64(b, v)
+	}
+}
+
+func msgpackAppendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n < 1<<8:
+		b = append(b, 0xd9, byte(n))
+	case n < 1<<16:
+		b = append(b, 0xda, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdb)
+		b = appendUint32(b, uint32(n))
+	}
+
+	return append(b, s...)
+}
+
+func msgpackAppendBinary(b []byte, v []byte) []byte {
+	n := len(v)
+	switch {
+	case n < 1<<8:
+		b = append(b, 0xc4, byte(n))
+	case n < 1<<16:
+		b = append(b, 0xc5, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xc6)
+		b = appendUint32(b, uint32(n))
+	}
+
+	return append(b, v...)
+}
+
+func msgpackAppendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n < 1<<16:
+		return append(b, 0xdc, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdd)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+func msgpackAppendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n < 1<<16:
+		return append(b, 0xde, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdf)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+// msgpackAppendMap appends m with its keys in lexical order so the
+// encoding is deterministic.
+func msgpackAppendMap(b []byte, m map[string]interface{}) []byte {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b = msgpackAppendMapHeader(b, len(m))
+	for _, k := range keys {
+		b = msgpackAppendString(b, k)
+		b = msgpackAppend(b, m[k])
+	}
+
+	return b
+}
+
+// msgpackAppendEventTime appends t as a Fluent EventTime, a fixext8
+// of extension type 0 holding the seconds and nanoseconds.
+func msgpackAppendEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = appendUint32(b, uint32(t.Unix()))
+
+	return appendUint32(b, uint32(t.Nanosecond()))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+
+	return append(b, buf[:]...)
+}
+
+// msgpackDecode reads one value. Maps are returned as
+// map[string]interface{}, arrays as []interface{}, strings and
+// binaries as string, and integers as int64. Floats and extensions
+// are not needed for acknowledgements and are rejected.
+func msgpackDecode(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return msgpackDecodeMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return msgpackDecodeArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return msgpackDecodeString(r, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		n, err := msgpackReadUint(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeString(r, int(n))
+	case 0xc5, 0xda:
+		n, err := msgpackReadUint(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeString(r, int(n))
+	case 0xc6, 0xdb:
+		n, err := msgpackReadUint(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeString(r, int(n))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := msgpackReadUint(r, 1<<(c-0xcc))
+		return int64(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := msgpackReadUint(r, size)
+		shift := uint(64 - 8*size)
+		return int64(n<<shift) >> shift, err
+	case 0xdc:
+		n, err := msgpackReadUint(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeArray(r, int(n))
+	case 0xdd:
+		n, err := msgpackReadUint(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeArray(r, int(n))
+	case 0xde:
+		n, err := msgpackReadUint(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeMap(r, int(n))
+	case 0xdf:
+		n, err := msgpackReadUint(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackDecodeMap(r, int(n))
+	}
+
+	return nil, fmt.Errorf("unsupported msgpack type %#x", c)
+}
+
+func msgpackReadUint(r *bufio.Reader, size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[8-size:]); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func msgpackDecodeString(r *bufio.Reader, n int) (interface{}, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+
+	return string(buf), nil
+}
+
+func msgpackDecodeArray(r *bufio.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+
+	return a, nil
+}
+
+func msgpackDecodeMap(r *bufio.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+
+	return m, nil
+}
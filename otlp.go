@@ -0,0 +1,471 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultOTLPURL is the default OTLP/HTTP logs endpoint of a
+	// local collector.
+	DefaultOTLPURL = "http://localhost:4318/v1/logs"
+
+	// DefaultOTLPScopeName is the default instrumentation scope
+	// name of exported records.
+	DefaultOTLPScopeName = "github.com/apatters/go-conlog"
+)
+
+// SpanContext holds the trace and span IDs of the span an entry was
+// logged in.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+}
+
+// IsValid returns true if both the trace and span IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying sc. Use it when the
+// trace and span IDs are not managed by an OpenTelemetry SDK, e.g.,
+// when they are taken from an incoming request header.
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanFromContext returns the SpanContext set with ContextWithSpan.
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+
+	return sc, ok && sc.IsValid()
+}
+
+// OTLPOptions configure an OTLPSink.
+type OTLPOptions struct {
+	// URL is the OTLP/HTTP logs endpoint. The default is
+	// DefaultOTLPURL.
+	URL string
+
+	// ServiceName is the service.name resource attribute.
+	ServiceName string
+
+	// ResourceAttributes are additional resource attributes,
+	// e.g., {"service.version": "1.2.3"}.
+	ResourceAttributes map[string]interface{}
+
+	// ScopeName is the instrumentation scope name. The default is
+	// DefaultOTLPScopeName.
+	ScopeName string
+
+	// Headers are added to each export request, e.g., for
+	// authentication.
+	Headers map[string]string
+
+	// SpanExtractor returns the span an entry's context belongs
+	// to. The default is SpanFromContext. Set it to bridge an
+	// OpenTelemetry SDK, e.g., using
+	// trace.SpanContextFromContext.
+	SpanExtractor func(ctx context.Context) (SpanContext, bool)
+
+	// Levels are the levels exported. The default is every level
+	// returned by GetAllLevels.
+	Levels []Level
+
+	// BatchSize, BatchInterval, and QueueSize control batching.
+	// Entries arriving when the queue is full are dropped and
+	// counted. The defaults are DefaultBatchSize,
+	// DefaultBatchInterval, and DefaultQueueSize.
+	BatchSize     int
+	BatchInterval time.Duration
+	QueueSize     int
+
+	// MaxRetries, MinBackoff, and MaxBackoff control retrying
+	// failed exports. The defaults are DefaultMaxRetries,
+	// DefaultMinBackoff, and DefaultMaxBackoff.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Client is the HTTP client used for exports. The default has
+	// a 10 second timeout.
+	Client *http.Client
+
+	// ErrorHandler is called when a batch cannot be exported. The
+	// default writes the error to stderr.
+	ErrorHandler func(err error)
+}
+
+// NewOTLPOptions is the constructor for OTLPOptions. An empty url
+// selects DefaultOTLPURL.
+func NewOTLPOptions(url string, serviceName string) *OTLPOptions {
+	if url == "" {
+		url = DefaultOTLPURL
+	}
+
+	return &OTLPOptions{
+		URL:           url,
+		ServiceName:   serviceName,
+		ScopeName:     DefaultOTLPScopeName,
+		SpanExtractor: SpanFromContext,
+		BatchSize:     DefaultBatchSize,
+		BatchInterval: DefaultBatchInterval,
+		QueueSize:     DefaultQueueSize,
+		MaxRetries:    DefaultMaxRetries,
+		MinBackoff:    DefaultMinBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OTLPSink is a Hook that converts entries to the OpenTelemetry logs
+// data model and exports them in batches over OTLP/HTTP using the
+// JSON encoding. Add it to a logger with AddHook:
+//
+//	sink, err := conlog.NewOTLPSink(conlog.NewOTLPOptions("", "myapp"))
+//	log.AddHook(sink)
+//	defer sink.Close()
+//	log.WithContext(ctx).Info("handled request")
+//
+// The level sets the severity, the message is the body, and fields
+// and the caller are attributes. Entries logged with WithContext carry
+// the trace and span IDs found by SpanExtractor. Until it is closed,
+// the sink is flushed by HandleExit so the last records are exported
+// before the program exits.
+type OTLPSink struct {
+	options  OTLPOptions
+	resource []otlpKeyValue
+	header   http.Header
+	batcher  *batcher
+	retrier  retrier
+}
+
+var _ Hook = (*OTLPSink)(nil)
+
+// The OTLP JSON encoding of the logs data model
+// (opentelemetry/proto/logs/v1/logs.proto). 64-bit integers are
+// strings and IDs are hex strings.
+type otlpLogsData struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	Flags                uint32         `json:"flags,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string          `json:"stringValue,omitempty"`
+	BoolValue   *bool            `json:"boolValue,omitempty"`
+	IntValue    *string          `json:"intValue,omitempty"`
+	DoubleValue *float64         `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKvlistValue `json:"kvlistValue,omitempty"`
+	BytesValue  *string          `json:"bytesValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKvlistValue struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// NewOTLPSink is the constructor for OTLPSink. A nil options uses
+// NewOTLPOptions("", "").
+func NewOTLPSink(options *OTLPOptions) (*OTLPSink, error) {
+	if options == nil {
+		options = NewOTLPOptions("", "")
+	}
+	s := &OTLPSink{
+		options: *options,
+		header:  make(http.Header),
+	}
+	if s.options.URL == "" {
+		s.options.URL = DefaultOTLPURL
+	}
+	if s.options.ScopeName == "" {
+		s.options.ScopeName = DefaultOTLPScopeName
+	}
+	if s.options.SpanExtractor == nil {
+		s.options.SpanExtractor = SpanFromContext
+	}
+	if s.options.Levels == nil {
+		s.options.Levels = GetAllLevels()
+	}
+	if s.options.Client == nil {
+		s.options.Client = http.DefaultClient
+	}
+
+	attributes := make(Fields, len(s.options.ResourceAttributes)+1)
+	for k, v := range s.options.ResourceAttributes {
+		attributes[k] = v
+	}
+	if s.options.ServiceName != "" {
+		attributes["service.name"] = s.options.ServiceName
+	}
+	s.resource = otlpAttributes(attributes)
+	for k, v := range s.options.Headers {
+		s.header.Set(k, v)
+	}
+	s.header.Set("Content-Type", "application/json")
+
+	s.retrier = newRetrier(s.options.MaxRetries, s.options.MinBackoff, s.options.MaxBackoff)
+	s.batcher = newBatcher(s.options.QueueSize, s.options.BatchSize, s.options.BatchInterval, s.push)
+	registerHook(s)
+
+	return s, nil
+}
+
+// Levels returns the levels the sink is fired for.
+func (s *OTLPSink) Levels() []Level {
+	return s.options.Levels
+}
+
+// Fire converts the entry to an OTLP log record, with the trace and
+// span ids of its context, and queues it for the next export. Records
+// that do not fit in the queue are dropped and counted by Dropped.
+func (s *OTLPSink) Fire(entry *Entry) error {
+	attributes := make(Fields, len(entry.Fields)+3)
+	for k, v := range entry.Fields {
+		attributes[k] = v
+	}
+	if entry.HasCaller() {
+		attributes["code.function"] = entry.Caller.Function
+		attributes["code.filepath"] = entry.Caller.File
+		attributes["code.lineno"] = entry.Caller.Line
+	}
+
+	number, text := otlpSeverity(entry.Level)
+	body := strings.TrimSuffix(entry.Message, "\n")
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(entry.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       number,
+		SeverityText:         text,
+		Body:                 otlpAnyValue{StringValue: &body},
+		Attributes:           otlpAttributes(attributes),
+	}
+	if entry.Context != nil {
+		if sc, ok := s.options.SpanExtractor(entry.Context); ok {
+			record.TraceID = hex.EncodeToString(sc.TraceID[:])
+			record.SpanID = hex.EncodeToString(sc.SpanID[:])
+			record.Flags = uint32(sc.TraceFlags)
+		}
+	}
+	s.batcher.add(record)
+
+	return nil
+}
+
+// Flush exports all queued entries and waits for the export to
+// finish.
+func (s *OTLPSink) Flush() {
+	s.batcher.flush()
+}
+
+// Close exports all queued entries and stops the sink. Entries fired
+// after Close are dropped.
+func (s *OTLPSink) Close() error {
+	unregisterHook(s)
+	s.batcher.close()
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was
+// full or the sink was closed.
+func (s *OTLPSink) Dropped() uint64 {
+	return s.batcher.droppedCount()
+}
+
+// push exports a batch. It is called by the batcher.
+func (s *OTLPSink) push(batch []interface{}) {
+	records := make([]otlpLogRecord, len(batch))
+	for i, item := range batch {
+		records[i] = item.(otlpLogRecord)
+	}
+	body, err := json.Marshal(otlpLogsData{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{Attributes: s.resource},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: s.options.ScopeName},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err == nil {
+		err = s.retrier.do(func() error {
+			return postBatch(s.options.Client, s.options.URL, s.header, body)
+		})
+	}
+	if err != nil {
+		handleError(s.options.ErrorHandler, fmt.Errorf("failed to export %d entries to %s, %v", len(batch), s.options.URL, err))
+	}
+}
+
+// otlpSeverity maps a level to an OpenTelemetry severity number and
+// text. Registered levels map like their built-in severity but keep
+// their own name.
+func otlpSeverity(level Level) (int, string) {
+	text := strings.ToUpper(level.String())
+	switch level.severity() {
+	case TraceLevel:
+		return 1, text
+	case DebugLevel:
+		return 5, text
+	case InfoLevel, PrintLevel:
+		return 9, text
+	case WarnLevel:
+		return 13, text
+	case ErrorLevel:
+		return 17, text
+	case FatalLevel:
+		return 21, text
+	case PanicLevel:
+		return 24, text
+	default:
+		return 0, text
+	}
+}
+
+// otlpAttributes converts fields to attributes sorted by key.
+func otlpAttributes(fields Fields) []otlpKeyValue {
+	if len(fields) == 0 {
+		return nil
+	}
+	attributes := make([]otlpKeyValue, 0, len(fields))
+	for _, k := range fields.sortedKeys() {
+		attributes = append(attributes, otlpKeyValue{Key: k, Value: otlpValue(fields[k])})
+	}
+
+	return attributes
+}
+
+// otlpValue converts a field value to an AnyValue. Types without an
+// AnyValue equivalent are converted to their fmt.Sprint string.
+func otlpValue(v interface{}) otlpAnyValue {
+	intValue := func(i int64) otlpAnyValue {
+		s := strconv.FormatInt(i, 10)
+		return otlpAnyValue{IntValue: &s}
+	}
+	// OTLP int values are signed 64-bit; larger unsigned values
+	// are sent as strings rather than wrapping around.
+	uintValue := func(u uint64) otlpAnyValue {
+		if u > math.MaxInt64 {
+			s := strconv.FormatUint(u, 10)
+			return otlpAnyValue{StringValue: &s}
+		}
+		return intValue(int64(u))
+	}
+	doubleValue := func(f float64) otlpAnyValue {
+		// JSON cannot represent these.
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			s := strconv.FormatFloat(f, 'g', -1, 64)
+			return otlpAnyValue{StringValue: &s}
+		}
+		return otlpAnyValue{DoubleValue: &f}
+	}
+
+	switch v := v.(type) {
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int8:
+		return intValue(int64(v))
+	case int16:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint:
+		return uintValue(uint64(v))
+	case uint8:
+		return intValue(int64(v))
+	case uint16:
+		return intValue(int64(v))
+	case uint32:
+		return intValue(int64(v))
+	case uint64:
+		return uintValue(v)
+	case float32:
+		return doubleValue(float64(v))
+	case float64:
+		return doubleValue(v)
+	case []byte:
+		s := base64.StdEncoding.EncodeToString(v)
+		return otlpAnyValue{BytesValue: &s}
+	case []interface{}:
+		values := make([]otlpAnyValue, len(v))
+		for i, e := range v {
+			values[i] = otlpValue(e)
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case []string:
+		values := make([]otlpAnyValue, len(v))
+		for i, e := range v {
+			values[i] = otlpValue(e)
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case Fields:
+		return otlpAnyValue{KvlistValue: &otlpKvlistValue{Values: otlpAttributes(v)}}
+	case map[string]interface{}:
+		return otlpAnyValue{KvlistValue: &otlpKvlistValue{Values: otlpAttributes(v)}}
+	case error:
+		s := v.Error()
+		return otlpAnyValue{StringValue: &s}
+	default:
+		s := fmt.Sprint(v)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// otlpCollector records the export requests sent to it.
+type otlpCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	headers  http.Header
+}
+
+func newOTLPCollector(t *testing.T) *otlpCollector {
+	c := &otlpCollector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("bad export request: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, request)
+		c.headers = r.Header
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+
+	return c
+}
+
+// get returns the value at path in the first request, e.g.,
+// get("resourceLogs", 0, "resource").
+func (c *otlpCollector) get(path ...interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.requests) == 0 {
+		return nil
+	}
+	var v interface{} = c.requests[0]
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			v = m[p]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || p >= len(a) {
+				return nil
+			}
+			v = a[p]
+		}
+	}
+
+	return v
+}
+
+func (c *otlpCollector) record(i int) map[string]interface{} {
+	record, _ := c.get("resourceLogs", 0, "scopeLogs", 0, "logRecords", i).(map[string]interface{})
+	return record
+}
+
+func TestOTLPSink(t *testing.T) {
+	collector := newOTLPCollector(t)
+	defer collector.Close()
+	options := conlog.NewOTLPOptions(collector.URL+"/v1/logs", "test-service")
+	options.ResourceAttributes = map[string]interface{}{"service.version": "1.2.3"}
+	options.Headers = map[string]string{"Authorization": "Bearer token"}
+	sink, err := conlog.NewOTLPSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	log := newHookLogger(sink)
+	log.SetLevel(conlog.TraceLevel)
+
+	ctx := conlog.ContextWithSpan(context.Background(), conlog.SpanContext{
+		TraceID:    [16]byte{0x01, 0x02, 15: 0xff},
+		SpanID:     [8]byte{0x0a, 7: 0x0b},
+		TraceFlags: 1,
+	})
+	before := time.Now()
+	log.Trace("Trace test")
+	log.WithContext(ctx).WithFields(conlog.Fields{
+		"user":  "bob",
+		"count": 3,
+		"ratio": 0.5,
+		"ok":    true,
+		"tags":  []string{"a", "b"},
+	}).Warn("Warn test")
+	log.Error("Error test")
+	sink.Flush()
+
+	assert.Equal(t, "Bearer token", collector.headers.Get("Authorization"))
+	assert.Equal(t, "application/json", collector.headers.Get("Content-Type"))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "test-service"}},
+		map[string]interface{}{"key": "service.version", "value": map[string]interface{}{"stringValue": "1.2.3"}},
+	}, collector.get("resourceLogs", 0, "resource", "attributes"))
+	assert.Equal(t, conlog.DefaultOTLPScopeName, collector.get("resourceLogs", 0, "scopeLogs", 0, "scope", "name"))
+
+	trace := collector.record(0)
+	t.Logf("trace = %v", trace)
+	assert.Equal(t, float64(1), trace["severityNumber"])
+	assert.Equal(t, "TRACE", trace["severityText"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "Trace test"}, trace["body"])
+	assert.Nil(t, trace["traceId"])
+	assert.NotContains(t, trace, "attributes")
+	timeUnixNano, err := strconv.ParseInt(trace["timeUnixNano"].(string), 10, 64)
+	assert.NoError(t, err)
+	assert.True(t, timeUnixNano >= before.UnixNano())
+
+	warn := collector.record(1)
+	t.Logf("warn = %v", warn)
+	assert.Equal(t, float64(13), warn["severityNumber"])
+	assert.Equal(t, "WARNING", warn["severityText"])
+	assert.Equal(t, "010200000000000000000000000000ff", warn["traceId"])
+	assert.Equal(t, "0a0000000000000b", warn["spanId"])
+	assert.Equal(t, float64(1), warn["flags"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "count", "value": map[string]interface{}{"intValue": "3"}},
+		map[string]interface{}{"key": "ok", "value": map[string]interface{}{"boolValue": true}},
+		map[string]interface{}{"key": "ratio", "value": map[string]interface{}{"doubleValue": 0.5}},
+		map[string]interface{}{"key": "tags", "value": map[string]interface{}{"arrayValue": map[string]interface{}{
+			"values": []interface{}{
+				map[string]interface{}{"stringValue": "a"},
+				map[string]interface{}{"stringValue": "b"},
+			},
+		}}},
+		map[string]interface{}{"key": "user", "value": map[string]interface{}{"stringValue": "bob"}},
+	}, warn["attributes"])
+
+	errorRecord := collector.record(2)
+	assert.Equal(t, float64(17), errorRecord["severityNumber"])
+	assert.Equal(t, "ERROR", errorRecord["severityText"])
+}
+
+func TestOTLPSink_Unsigned(t *testing.T) {
+	collector := newOTLPCollector(t)
+	defer collector.Close()
+	sink, err := conlog.NewOTLPSink(conlog.NewOTLPOptions(collector.URL, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	log := newHookLogger(sink)
+
+	// Unsigned values too large for an OTLP int are sent as strings.
+	log.WithFields(conlog.Fields{
+		"a": uint(7),
+		"b": ^uint(0),
+		"c": uint64(math.MaxInt64),
+		"d": uint64(math.MaxUint64),
+	}).Info("Info test")
+	sink.Flush()
+
+	b := map[string]interface{}{"intValue": strconv.FormatUint(uint64(^uint(0)), 10)}
+	if uint64(^uint(0)) > math.MaxInt64 {
+		b = map[string]interface{}{"stringValue": strconv.FormatUint(uint64(^uint(0)), 10)}
+	}
+	info := collector.record(0)
+	t.Logf("info = %v", info)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "a", "value": map[string]interface{}{"intValue": "7"}},
+		map[string]interface{}{"key": "b", "value": b},
+		map[string]interface{}{"key": "c", "value": map[string]interface{}{"intValue": "9223372036854775807"}},
+		map[string]interface{}{"key": "d", "value": map[string]interface{}{"stringValue": "18446744073709551615"}},
+	}, info["attributes"])
+}
+
+func TestOTLPSink_SpanExtractor(t *testing.T) {
+	collector := newOTLPCollector(t)
+	defer collector.Close()
+	type key struct{}
+	options := conlog.NewOTLPOptions(collector.URL, "")
+	options.SpanExtractor = func(ctx context.Context) (conlog.SpanContext, bool) {
+		id, ok := ctx.Value(key{}).(byte)
+		return conlog.SpanContext{TraceID: [16]byte{15: id}, SpanID: [8]byte{7: id}}, ok
+	}
+	sink, err := conlog.NewOTLPSink(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	log := newHookLogger(sink)
+
+	log.WithContext(context.WithValue(context.Background(), key{}, byte(7))).Info("Info test")
+	sink.Flush()
+
+	record := collector.record(0)
+	t.Logf("record = %v", record)
+	assert.Equal(t, "00000000000000000000000000000007", record["traceId"])
+	assert.Equal(t, "0000000000000007", record["spanId"])
+	assert.Nil(t, collector.get("resourceLogs", 0, "resource", "attributes"))
+}
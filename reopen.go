@@ -0,0 +1,271 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Reopener is implemented by outputs that can close and reopen their
+// underlying file, e.g., after it has been moved by logrotate.
+type Reopener interface {
+	Reopen() error
+}
+
+// ReopenableFile is an io.WriteCloser that appends to a named file
+// which can be reopened by name with Reopen. Use it instead of an
+// *os.File with Logger.SetOutput when the file is rotated by an
+// external tool such as logrotate.
+type ReopenableFile struct {
+	name string
+	mode os.FileMode
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewReopenableFile opens, or creates with mode, the file name for
+// appending.
+func NewReopenableFile(name string, mode os.FileMode) (*ReopenableFile, error) {
+	f := &ReopenableFile{
+		name: name,
+		mode: mode,
+	}
+	file, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+
+	return f, nil
+}
+
+// Name returns the name of the file being written.
+func (f *ReopenableFile) Name() string {
+	return f.name
+}
+
+// Write writes p to the file.
+func (f *ReopenableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	return f.file.Write(p)
+}
+
+// Reopen closes the file and opens it again by name, creating it if
+// it has been moved away. If the file cannot be opened the old file
+// is kept.
+func (f *ReopenableFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	file, err := f.open()
+	if err != nil {
+		return err
+	}
+	old := f.file
+	f.file = file
+
+	return old.Close()
+}
+
+// Sync commits the file to stable storage.
+func (f *ReopenableFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	return f.file.Sync()
+}
+
+// Close closes the file.
+func (f *ReopenableFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *ReopenableFile) open() (*os.File, error) {
+	return os.OpenFile(f.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.mode)
+}
+
+// Reopen reopens the file by name without rotating it. It lets a
+// RotatingFile follow an external tool that moved it.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	old := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return old.Close()
+}
+
+// Reopen reopens every output of the logger that implements
+// Reopener. The logger mutexes are held throughout so no entry is lost
+// or split between the old and new files. The first error is
+// returned.
+func (log *Logger) Reopen() error {
+	log.writeMu.Lock()
+	defer log.writeMu.Unlock()
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	writers := []interface{}{log.out, log.errOut}
+	for _, w := range log.levelOutputs {
+		writers = append(writers, w)
+	}
+
+	return reopenAll(writers)
+}
+
+// Reopen reopens the outputs of every logger that implements
+// Reopener. The first error is returned.
+func (logs *Loggers) Reopen() error {
+	var members []interface{}
+	for _, logger := range logs.members() {
+		members = append(members, logger)
+	}
+
+	return reopenAll(members)
+}
+
+// reopenAll calls Reopen once on each distinct Reopener in values.
+func reopenAll(values []interface{}) error {
+	var firstErr error
+	seen := make(map[Reopener]bool)
+	for _, v := range values {
+		r, ok := v.(Reopener)
+		if !ok {
+			continue
+		}
+		if reflect.TypeOf(r).Comparable() {
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+		}
+		if err := r.Reopen(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// ReopenWatcher reopens the outputs of its registered loggers when the
+// process receives SIGHUP. It is opt-in; nothing is reopened unless a
+// ReopenWatcher is created. A typical logrotate configuration uses
+// postrotate to send SIGHUP to the program.
+type ReopenWatcher struct {
+	mu           sync.Mutex
+	reopeners    []Reopener
+	errorHandler func(err error)
+	signals      chan os.Signal
+	done         chan struct{}
+	stopOnce     sync.Once
+}
+
+// NewReopenWatcher is the constructor for ReopenWatcher. It registers
+// reopeners, typically *Logger or *Loggers, and starts watching for
+// SIGHUP.
+func NewReopenWatcher(reopeners ...Reopener) *ReopenWatcher {
+	w := &ReopenWatcher{
+		reopeners: reopeners,
+		signals:   make(chan os.Signal, 1),
+		done:      make(chan struct{}),
+	}
+	signal.Notify(w.signals, syscall.SIGHUP)
+	go w.watch()
+
+	return w
+}
+
+// Add registers more reopeners.
+func (w *ReopenWatcher) Add(reopeners ...Reopener) {
+	w.mu.Lock()
+	w.reopeners = append(w.reopeners, reopeners...)
+	w.mu.Unlock()
+}
+
+// SetErrorHandler sets the function called with errors from reopens
+// triggered by SIGHUP. The default writes the error to stderr.
+func (w *ReopenWatcher) SetErrorHandler(fn func(err error)) {
+	w.mu.Lock()
+	w.errorHandler = fn
+	w.mu.Unlock()
+}
+
+// Reopen reopens the outputs of all registered reopeners as if SIGHUP
+// had been received. The first error is returned.
+func (w *ReopenWatcher) Reopen() error {
+	w.mu.Lock()
+	values := make([]interface{}, len(w.reopeners))
+	for i, r := range w.reopeners {
+		values[i] = r
+	}
+	w.mu.Unlock()
+
+	return reopenAll(values)
+}
+
+// Stop stops watching for SIGHUP.
+func (w *ReopenWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		signal.Stop(w.signals)
+		close(w.done)
+	})
+}
+
+func (w *ReopenWatcher) watch() {
+	for {
+		select {
+		case <-w.signals:
+			if err := w.Reopen(); err != nil {
+				w.handleError(err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *ReopenWatcher) handleError(err error) {
+	w.mu.Lock()
+	fn := w.errorHandler
+	w.mu.Unlock()
+	if fn != nil {
+		fn(err)
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Failed to reopen log output, %v\n", err)
+}
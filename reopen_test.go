@@ -0,0 +1,81 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReopenLogger(t *testing.T, dir string) (*conlog.Logger, *conlog.ReopenableFile, string) {
+	name := filepath.Join(dir, "app.log")
+	f, err := conlog.NewReopenableFile(name, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := conlog.NewLogger()
+	log.SetOutput(f)
+	log.SetErrorOutput(f)
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.LogLevelFmt = conlog.LogLevelFormatShort
+	log.SetFormatter(formatter)
+
+	return log, f, name
+}
+
+func TestLogger_Reopen(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	log, f, name := newReopenLogger(t, dir)
+	defer f.Close()
+
+	log.Info("Before rotate")
+	assert.NoError(t, os.Rename(name, name+".1"))
+	log.Error("After rename")
+	assert.NoError(t, log.Reopen())
+	log.Info("After reopen")
+
+	var tests = []struct {
+		name string
+		cmp  string
+	}{
+		{name + ".1", "INFO Before rotate\nERRO After rename\n"},
+		{name, "INFO After reopen\n"},
+	}
+	for _, test := range tests {
+		contents, _ := ioutil.ReadFile(test.name)
+		t.Logf("out string = %q", string(contents))
+		t.Logf("cmp string = %q", test.cmp)
+		assert.Equal(t, test.cmp, string(contents))
+	}
+}
+
+func TestReopenWatcher_Reopen(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	log, f, name := newReopenLogger(t, dir)
+	defer f.Close()
+	loggers := conlog.NewLoggers(log)
+	watcher := conlog.NewReopenWatcher(loggers)
+	defer watcher.Stop()
+
+	log.Info("Before rotate")
+	assert.NoError(t, os.Rename(name, name+".1"))
+	assert.NoError(t, watcher.Reopen())
+	log.Info("After reopen")
+
+	contents, _ := ioutil.ReadFile(name)
+	assert.Equal(t, "INFO After reopen\n", string(contents))
+
+	assert.NoError(t, f.Close())
+	assert.Equal(t, os.ErrClosed, watcher.Reopen())
+}
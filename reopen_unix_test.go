@@ -0,0 +1,45 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package conlog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReopenWatcher_SIGHUP(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	log, f, name := newReopenLogger(t, dir)
+	defer f.Close()
+	watcher := conlog.NewReopenWatcher(log)
+	defer watcher.Stop()
+
+	log.Info("Before rotate")
+	assert.NoError(t, os.Rename(name, name+".1"))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(name); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.Info("After reopen")
+
+	contents, _ := ioutil.ReadFile(name)
+	assert.Equal(t, "INFO After reopen\n", string(contents))
+}
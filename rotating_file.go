@@ -0,0 +1,364 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRotatingFileMode is the permission used to create log files
+// by RotatingFile.
+const DefaultRotatingFileMode os.FileMode = 0644
+
+// rotatingFileTimestampFormat is used to name backups. It sorts
+// lexically and contains no characters that are awkward in file
+// names.
+const rotatingFileTimestampFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is appended to the name of compressed backups.
+const compressSuffix = ".gz"
+
+// RotatingFileOptions control when a RotatingFile is rotated and how
+// its backups are retained.
+type RotatingFileOptions struct {
+	// MaxSize is the size in bytes at which the file is
+	// rotated. Zero disables rotation by size.
+	MaxSize int64
+
+	// Interval is the age at which the file is rotated. Zero
+	// disables rotation by time.
+	Interval time.Duration
+
+	// MaxBackups is the maximum number of backups to keep. Zero
+	// keeps all backups.
+	MaxBackups int
+
+	// MaxAge is the maximum age of a backup, based on the time
+	// encoded in its name. Zero keeps all backups.
+	MaxAge time.Duration
+
+	// Compress enables gzip compression of backups. Backups are
+	// compressed in the background.
+	Compress bool
+
+	// Mode is the permission used when creating the file.
+	Mode os.FileMode
+}
+
+// NewRotatingFileOptions is the constructor for RotatingFileOptions.
+func NewRotatingFileOptions() *RotatingFileOptions {
+	return &RotatingFileOptions{
+		Mode: DefaultRotatingFileMode,
+	}
+}
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates
+// it when it grows too large or too old. A rotated file is renamed
+// with a timestamp between its base name and extension, e.g.,
+// app.log becomes app-2019-01-02T15-04-05.000.log.
+//
+// A RotatingFile is safe for concurrent use so the same RotatingFile
+// can be used for both Logger.SetOutput and Logger.SetErrorOutput.
+type RotatingFile struct {
+	// Options control rotation and retention. They should not be
+	// changed after NewRotatingFile is called.
+	Options RotatingFileOptions
+
+	name     string
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	// millMu serializes the background compress/prune passes.
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+}
+
+// NewRotatingFile opens, or creates, the file name for appending and
+// returns a RotatingFile that writes to it. A nil options uses
+// NewRotatingFileOptions.
+func NewRotatingFile(name string, options *RotatingFileOptions) (*RotatingFile, error) {
+	if options == nil {
+		options = NewRotatingFileOptions()
+	}
+	f := &RotatingFile{
+		Options: *options,
+		name:    name,
+	}
+	if f.Options.Mode == 0 {
+		f.Options.Mode = DefaultRotatingFileMode
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Name returns the name of the file being written.
+func (f *RotatingFile) Name() string {
+	return f.name
+}
+
+// Write writes p to the file, rotating it first if the write would
+// exceed MaxSize or the file is older than Interval.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.needsRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Rotate closes the current file, renames it to a backup, and opens a
+// new file.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	return f.rotate()
+}
+
+// Sync commits the current file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for any background compression to
+// finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if !f.closed {
+		f.closed = true
+		err = f.file.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+
+	return err
+}
+
+func (f *RotatingFile) needsRotate(n int64) bool {
+	if f.Options.MaxSize > 0 && f.size > 0 && f.size+n > f.Options.MaxSize {
+		return true
+	}
+
+	return f.Options.Interval > 0 && time.Since(f.openedAt) >= f.Options.Interval
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.Options.Mode)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+
+	return nil
+}
+
+// rotate renames the current file to a backup and opens a new one. If
+// the close, the rename, or the open fails, the current file is
+// reopened so later writes are not lost to a closed file.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		f.reopen("", false)
+		return err
+	}
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.name, backup); err != nil && !os.IsNotExist(err) {
+		f.reopen(backup, false)
+		return err
+	}
+	if err := f.open(); err != nil {
+		f.reopen(backup, true)
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.mill(backup)
+	}()
+
+	return nil
+}
+
+// reopen reopens the current file after a failed rotation, first
+// renaming the backup back if it was renamed. If the file cannot be
+// reopened, the RotatingFile is closed and writes return os.ErrClosed.
+func (f *RotatingFile) reopen(backup string, renamed bool) {
+	if renamed {
+		if err := os.Rename(backup, f.name); err != nil {
+			f.closed = true
+			return
+		}
+	}
+	if err := f.open(); err != nil {
+		f.closed = true
+	}
+}
+
+// backupName returns an unused backup name for a rotation at t.
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	for {
+		name := filepath.Join(dir, prefix+t.Format(rotatingFileTimestampFormat)+ext)
+		_, err := os.Stat(name)
+		_, errGz := os.Stat(name + compressSuffix)
+		if os.IsNotExist(err) && os.IsNotExist(errGz) {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// nameParts splits the file name into its directory, the prefix of
+// its backups, and its extension.
+func (f *RotatingFile) nameParts() (dir string, prefix string, ext string) {
+	dir = filepath.Dir(f.name)
+	base := filepath.Base(f.name)
+	ext = filepath.Ext(base)
+
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// mill compresses the new backup and removes backups that exceed
+// MaxBackups or MaxAge. Errors are ignored as there is nowhere to
+// report them.
+func (f *RotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.Options.Compress {
+		if err := compressFile(backup, f.Options.Mode); err == nil {
+			os.Remove(backup)
+		}
+	}
+	if f.Options.MaxBackups <= 0 && f.Options.MaxAge <= 0 {
+		return
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-f.Options.MaxAge)
+	for i, b := range backups {
+		if (f.Options.MaxBackups > 0 && i >= f.Options.MaxBackups) ||
+			(f.Options.MaxAge > 0 && b.timestamp.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+type rotatingFileBackup struct {
+	path      string
+	timestamp time.Time
+}
+
+// backups returns the existing backups, newest first.
+func (f *RotatingFile) backups() ([]rotatingFileBackup, error) {
+	dir, prefix, ext := f.nameParts()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []rotatingFileBackup
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(stamp, compressSuffix)
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(rotatingFileTimestampFormat, strings.TrimSuffix(stamp, ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, rotatingFileBackup{
+			path:      filepath.Join(dir, name),
+			timestamp: t,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+
+	return backups, nil
+}
+
+// compressFile gzips name to name.gz. The result is written to a
+// temporary file first so a partial file is never mistaken for a
+// backup.
+func compressFile(name string, mode os.FileMode) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := name + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, name+compressSuffix)
+}
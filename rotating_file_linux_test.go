@@ -0,0 +1,74 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package conlog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// closeFileDescriptor closes the descriptor this process has open for
+// path behind the back of the *os.File that owns it, so closing the
+// *os.File fails.
+func closeFileDescriptor(t *testing.T, path string) {
+	dir := "/proc/self/fd"
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Skipf("%s not available: %v", dir, err)
+	}
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err != nil || target != path {
+			continue
+		}
+		fd, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if err := syscall.Close(fd); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	t.Fatalf("no descriptor open for %s", path)
+}
+
+func TestRotatingFile_CloseError(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	name := filepath.Join(dir, "app.log")
+	f, err := conlog.NewRotatingFile(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, err = f.Write([]byte("Before test\n"))
+	assert.NoError(t, err)
+	closeFileDescriptor(t, name)
+	assert.Error(t, f.Rotate())
+
+	// The file is reopened, not rotated, so writes still land in it.
+	_, err = f.Write([]byte("After test\n"))
+	assert.NoError(t, err)
+	out, err := ioutil.ReadFile(name)
+	assert.NoError(t, err)
+	cmp := "Before test\nAfter test\n"
+	t.Logf("out = %q", out)
+	t.Logf("cmp = %q", cmp)
+	assert.Equal(t, cmp, string(out))
+	assert.Empty(t, rotatingFileBackups(t, dir, "app-*.log"))
+}
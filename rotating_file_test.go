@@ -0,0 +1,197 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRotatingFileDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "conlog-rotate-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func rotatingFileBackups(t *testing.T, dir string, pattern string) []string {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(matches)
+
+	return matches
+}
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	options := conlog.NewRotatingFileOptions()
+	options.MaxSize = 10
+	options.MaxBackups = 2
+	name := filepath.Join(dir, "app.log")
+	f, err := conlog.NewRotatingFile(name, options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, s := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		_, err = f.Write([]byte(s))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+
+	contents, _ := ioutil.ReadFile(name)
+	assert.Equal(t, "line 4\n", string(contents))
+	backups := rotatingFileBackups(t, dir, "app-*.log")
+	t.Logf("backups = %q", backups)
+	if assert.Len(t, backups, 2) {
+		contents, _ = ioutil.ReadFile(backups[0])
+		assert.Equal(t, "line 2\n", string(contents))
+		contents, _ = ioutil.ReadFile(backups[1])
+		assert.Equal(t, "line 3\n", string(contents))
+	}
+
+	_, err = f.Write([]byte("closed\n"))
+	assert.Equal(t, os.ErrClosed, err)
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	options := conlog.NewRotatingFileOptions()
+	options.Compress = true
+	name := filepath.Join(dir, "app.log")
+	f, err := conlog.NewRotatingFile(name, options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	f.Write([]byte("compressed\n"))
+	assert.NoError(t, f.Rotate())
+	f.Write([]byte("current\n"))
+	assert.NoError(t, f.Close())
+
+	assert.Empty(t, rotatingFileBackups(t, dir, "app-*.log"))
+	backups := rotatingFileBackups(t, dir, "app-*.log.gz")
+	if assert.Len(t, backups, 1) {
+		gz, _ := os.Open(backups[0])
+		defer gz.Close()
+		zr, err := gzip.NewReader(gz)
+		if assert.NoError(t, err) {
+			contents, _ := ioutil.ReadAll(zr)
+			assert.Equal(t, "compressed\n", string(contents))
+		}
+	}
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	options := conlog.NewRotatingFileOptions()
+	options.Interval = 20 * time.Millisecond
+	name := filepath.Join(dir, "app.log")
+	f, err := conlog.NewRotatingFile(name, options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	f.Write([]byte("old\n"))
+	time.Sleep(2 * options.Interval)
+	f.Write([]byte("new\n"))
+	assert.NoError(t, f.Close())
+
+	contents, _ := ioutil.ReadFile(name)
+	assert.Equal(t, "new\n", string(contents))
+	assert.Len(t, rotatingFileBackups(t, dir, "app-*.log"), 1)
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	stale := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log")
+	assert.NoError(t, ioutil.WriteFile(stale, []byte("stale\n"), 0644))
+	other := filepath.Join(dir, "other-2000-01-01T00-00-00.000.log")
+	assert.NoError(t, ioutil.WriteFile(other, []byte("other\n"), 0644))
+
+	options := conlog.NewRotatingFileOptions()
+	options.MaxAge = time.Hour
+	f, err := conlog.NewRotatingFile(filepath.Join(dir, "app.log"), options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	f.Write([]byte("fresh\n"))
+	assert.NoError(t, f.Rotate())
+	assert.NoError(t, f.Close())
+
+	_, err = os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(other)
+	assert.NoError(t, err)
+	assert.Len(t, rotatingFileBackups(t, dir, "app-*.log"), 1)
+}
+
+func TestRotatingFile_SharedOutputs(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	options := conlog.NewRotatingFileOptions()
+	options.MaxSize = 256
+	name := filepath.Join(dir, "app.log")
+	f, err := conlog.NewRotatingFile(name, options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	log := conlog.NewLogger()
+	log.SetOutput(f)
+	log.SetErrorOutput(f)
+	formatter := conlog.NewStdFormatter()
+	formatter.Options.LogLevelFmt = conlog.LogLevelFormatShort
+	log.SetFormatter(formatter)
+
+	const numWriters, numLines = 4, 50
+	var wg sync.WaitGroup
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numLines; j++ {
+				log.Info("Info test")
+				log.Error("Error test")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, f.Close())
+
+	var lines []string
+	for _, file := range append(rotatingFileBackups(t, dir, "app-*.log"), name) {
+		contents, _ := ioutil.ReadFile(file)
+		lines = append(lines, strings.SplitAfter(string(contents), "\n")...)
+	}
+	var count int
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		count++
+		assert.Contains(t, []string{"INFO Info test\n", "ERRO Error test\n"}, line)
+	}
+	assert.Equal(t, 2*numWriters*numLines, count)
+}
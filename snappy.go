@@ -0,0 +1,103 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"encoding/binary"
+)
+
+// A minimal encoder for the snappy block format
+// (https://github.com/google/snappy/blob/master/format_description.txt)
+// used by the Loki protobuf push API. It finds matches with a single
+// hash table probe which is fast and good enough for log lines.
+
+const (
+	snappyTagLiteral = 0x00
+	snappyTagCopy1   = 0x01
+	snappyTagCopy2   = 0x02
+
+	snappyHashBits  = 14
+	snappyMaxOffset = 1<<16 - 1
+	snappyMinMatch  = 4
+)
+
+// snappyEncode returns the snappy block encoding of src.
+func snappyEncode(src []byte) []byte {
+	dst := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(src)+len(src)/6+32)
+	n := binary.PutUvarint(dst, uint64(len(src)))
+	dst = dst[:n]
+	if len(src) < snappyMinMatch+1 {
+		return snappyLiteral(dst, src)
+	}
+
+	var table [1 << snappyHashBits]int32
+	for i := range table {
+		table[i] = -1
+	}
+	litStart := 0
+	for i := 0; i+snappyMinMatch <= len(src); {
+		v := binary.LittleEndian.Uint32(src[i:])
+		h := (v * 0x1e35a7bd) >> (32 - snappyHashBits)
+		candidate := int(table[h])
+		table[h] = int32(i)
+		if candidate < 0 || i-candidate > snappyMaxOffset ||
+			binary.LittleEndian.Uint32(src[candidate:]) != v {
+			i++
+			continue
+		}
+
+		length := snappyMinMatch
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+		dst = snappyLiteral(dst, src[litStart:i])
+		dst = snappyCopy(dst, i-candidate, length)
+		i += length
+		litStart = i
+	}
+
+	return snappyLiteral(dst, src[litStart:])
+}
+
+// snappyLiteral appends a literal element for lit.
+func snappyLiteral(dst []byte, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := uint32(len(lit) - 1)
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|snappyTagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|snappyTagLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|snappyTagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+
+	return append(dst, lit...)
+}
+
+// snappyCopy appends copy elements for a match of length bytes at
+// offset. A copy element holds at most 64 bytes so long matches are
+// split, keeping every piece at least 4 bytes long.
+func snappyCopy(dst []byte, offset int, length int) []byte {
+	for length >= 68 {
+		dst = append(dst, 63<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+	if length > 64 {
+		dst = append(dst, 59<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+	if length < 12 && offset < 2048 {
+		return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|snappyTagCopy1, byte(offset))
+	}
+
+	return append(dst, byte(length-1)<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+}
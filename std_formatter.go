@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +22,7 @@ const (
 	green  = 32
 	yellow = 33
 	blue   = 36
+	gray   = 37
 )
 
 var (
@@ -78,6 +80,28 @@ const (
 	TimestampTypeElapsed
 )
 
+// CallerFormat is used to set how the caller is displayed in output
+// messages. The caller is only available when the logger has
+// ReportCaller enabled.
+type CallerFormat uint32
+
+const (
+	// CallerFormatUnknown is used for defensive programming. You
+	// should never see this.
+	CallerFormatUnknown = iota
+
+	// CallerFormatNone disables outputting the caller.
+	CallerFormatNone
+
+	// CallerFormatShort outputs the base name of the calling
+	// file and the line number, e.g., "main.go:42".
+	CallerFormatShort
+
+	// CallerFormatFull outputs the full path of the calling file
+	// and the line number, e.g., "/home/user/src/app/main.go:42".
+	CallerFormatFull
+)
+
 // FormattingOptions are options that control output format.
 type FormattingOptions struct {
 	// LogLevelFmt is the format used to display the log
@@ -99,6 +123,10 @@ type FormattingOptions struct {
 	// ElapsedTimestampFmt is the format string used to display
 	// elapsed time timestamps. Defaults to "%04d".
 	ElapsedTimestampFmt string
+
+	// CallerFmt controls how the caller is displayed. Defaults to
+	// CallerFormatNone.
+	CallerFmt CallerFormat
 }
 
 // NewFormattingOptions is the constructor for Formatting options.
@@ -109,6 +137,7 @@ func NewFormattingOptions() *FormattingOptions {
 		TimestampType:         TimestampTypeNone,
 		WallclockTimestampFmt: DefaultWallclockTimestampFormat,
 		ElapsedTimestampFmt:   DefaultElapsedTimestampFormat,
+		CallerFmt:             CallerFormatNone,
 	}
 }
 
@@ -155,7 +184,7 @@ func (f *StdFormatter) Format(entry *Entry) ([]byte, error) {
 
 	f.Do(func() { f.init(entry) })
 
-	if entry.Level == printLevel {
+	if entry.Level == PrintLevel {
 		_, err := fmt.Fprint(b, entry.Message)
 		if err != nil {
 			return []byte{}, nil
@@ -174,7 +203,7 @@ func (f *StdFormatter) printLeader(w io.Writer, entry *Entry) (n int) {
 
 	switch f.Options.LogLevelFmt {
 	case LogLevelFormatShort:
-		leader += strings.ToUpper(entry.Level.String())[0:4]
+		leader += entry.Level.shortName()
 		if f.Options.ShowLogLevelColors && f.isTerminal {
 			leader = f.colorOn(entry.Level) + leader + f.colorOff(entry.Level)
 		}
@@ -205,6 +234,21 @@ func (f *StdFormatter) printLeader(w io.Writer, entry *Entry) (n int) {
 	default:
 	}
 
+	if entry.HasCaller() {
+		var caller string
+		switch f.Options.CallerFmt {
+		case CallerFormatShort:
+			caller = fmt.Sprintf("%s:%d", filepath.Base(entry.Caller.File), entry.Caller.Line)
+		case CallerFormatFull:
+			caller = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+		default:
+		}
+		if len(caller) > 0 && len(leader) > 0 {
+			leader += " "
+		}
+		leader += caller
+	}
+
 	if len(leader) == 0 {
 		return 0
 	}
@@ -213,23 +257,32 @@ func (f *StdFormatter) printLeader(w io.Writer, entry *Entry) (n int) {
 }
 
 func (f *StdFormatter) printMessage(w io.Writer, entry *Entry) {
-	_, _ = fmt.Fprintf(w, "%s", entry.Message)
+	if len(entry.Fields) == 0 {
+		_, _ = fmt.Fprintf(w, "%s", entry.Message)
+		return
+	}
+
+	// Fields go between the message and its trailing newline.
+	msg := strings.TrimSuffix(entry.Message, "\n")
+	_, _ = fmt.Fprintf(w, "%s", msg)
+	f.printFields(w, entry)
+	if len(msg) != len(entry.Message) {
+		_, _ = fmt.Fprint(w, "\n")
+	}
 }
 
-func (f *StdFormatter) colorOn(level Level) (on string) {
-	var levelColor int
-	switch level {
-	case DebugLevel:
-		levelColor = blue
-	case WarnLevel:
-		levelColor = yellow
-	case ErrorLevel, FatalLevel, PanicLevel:
-		levelColor = red
-	default:
-		levelColor = green
+func (f *StdFormatter) printFields(w io.Writer, entry *Entry) {
+	colored := f.Options.ShowLogLevelColors && f.isTerminal
+	for _, key := range entry.Fields.sortedKeys() {
+		if colored {
+			key = f.colorOn(entry.Level) + key + f.colorOff(entry.Level)
+		}
+		_, _ = fmt.Fprintf(w, " %s=%s", key, formatFieldValue(entry.Fields[key]))
 	}
+}
 
-	on = fmt.Sprintf("\x1b[%dm", levelColor)
+func (f *StdFormatter) colorOn(level Level) (on string) {
+	on = fmt.Sprintf("\x1b[%dm", level.color())
 
 	return on
 }
@@ -25,6 +25,12 @@ func SetErrorOutput(w io.Writer) {
 	std.SetErrorOutput(w)
 }
 
+// SetLevelOutput sets the writer used for messages at level for the
+// standard logger.
+func SetLevelOutput(level Level, w io.Writer) {
+	std.SetLevelOutput(level, w)
+}
+
 // SetLevel sets the logger level for the standard logger.
 func SetLevel(level Level) {
 	std.SetLevel(level)
@@ -47,12 +53,47 @@ func GetPrintEnabled() bool {
 	return std.GetPrintEnabled()
 }
 
+// SetVerbosity sets the verbosity used to filter Print*-style output
+// for the standard logger.
+func SetVerbosity(verbosity int) {
+	std.SetVerbosity(verbosity)
+}
+
+// GetVerbosity returns the current verbosity of the standard logger.
+func GetVerbosity() int {
+	return std.GetVerbosity()
+}
+
+// V returns a Verbose whose Print* methods output to the standard
+// logger only if printing is enabled and the verbosity is at least v.
+func V(v int) Verbose {
+	return std.V(v)
+}
+
+// SetReportCaller enables/disables recording the calling file, line,
+// and function in entries logged to the standard logger.
+func SetReportCaller(enabled bool) {
+	std.SetReportCaller(enabled)
+}
+
+// GetReportCaller returns the ReportCaller setting for the standard
+// logger.
+func GetReportCaller() bool {
+	return std.GetReportCaller()
+}
+
 // SetFormatter sets the formatter used when printing entries to the
 // standard logger.
 func SetFormatter(formatter Formatter) {
 	std.SetFormatter(formatter)
 }
 
+// Logf logs a message at level to the standard logger. Arguments are
+// handled in the manner of fmt.Printf.
+func Logf(level Level, format string, args ...interface{}) {
+	std.Logf(level, format, args...)
+}
+
 // Printf prints a message to the standard logger. Ignores logging
 // levels. No logging levels, timestamps, or key files are added. The
 // equivalent of fmt.Fprintf.
@@ -60,6 +101,12 @@ func Printf(format string, args ...interface{}) {
 	std.Printf(format, args...)
 }
 
+// Tracef logs a message at level Trace to the standard
+// logger. Arguments are handled in the manner of fmt.Printf.
+func Tracef(format string, args ...interface{}) {
+	std.Tracef(format, args...)
+}
+
 // Debugf logs a message at level Debug to the standard
 // logger. Arguments are handled in the manner of fmt.Printf.
 func Debugf(format string, args ...interface{}) {
@@ -104,6 +151,11 @@ func Panicf(format string, args ...interface{}) {
 	std.Panicf(format, args...)
 }
 
+// Log logs a message at level to the standard logger.
+func Log(level Level, args ...interface{}) {
+	std.Log(level, args...)
+}
+
 // Print prints a message to the standard logger. It ignores logging
 // levels. No logging levels, timestamps, or key files are added. No
 // newline is added. The equivalent of fmt.Fprint.
@@ -111,6 +163,11 @@ func Print(args ...interface{}) {
 	std.Print(args...)
 }
 
+// Trace logs a message at level Trace to the standard logger.
+func Trace(args ...interface{}) {
+	std.Trace(args...)
+}
+
 // Debug logs a message at level Debug to the standard logger.
 func Debug(args ...interface{}) {
 	std.Debug(args...)
@@ -149,6 +206,12 @@ func Panic(args ...interface{}) {
 	std.Panic(args...)
 }
 
+// Logln logs a message at level to the standard logger. It is
+// equivalent to Log().
+func Logln(level Level, args ...interface{}) {
+	std.Logln(level, args...)
+}
+
 // Println a message to the standard logger. It ignores logging
 // levels. No logging levels, timestamps, or key files are added. The
 // equivalent of fmt.Fprintln().
@@ -156,6 +219,12 @@ func Println(args ...interface{}) {
 	std.Println(args...)
 }
 
+// Traceln logs a message at level Trace to the standard logger.  It
+// is equivalent to Trace().
+func Traceln(args ...interface{}) {
+	std.Traceln(args...)
+}
+
 // Debugln logs a message at level Debug to the standard logger.  It
 // is equivalent to Debug().
 func Debugln(args ...interface{}) {
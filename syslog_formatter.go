@@ -0,0 +1,275 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SyslogFormat selects the syslog message format.
+type SyslogFormat int
+
+const (
+	// SyslogFormatUnknown is an unknown format.
+	SyslogFormatUnknown SyslogFormat = iota
+
+	// SyslogFormatRFC3164 is the traditional BSD syslog format,
+	// e.g., "<14>Jan  2 15:04:05 host app[42]: message".
+	SyslogFormatRFC3164
+
+	// SyslogFormatRFC5424 is the structured syslog format, e.g.,
+	// "<14>1 2019-01-02T15:04:05.000000Z host app 42 - - message".
+	SyslogFormatRFC5424
+)
+
+// SyslogFacility is a syslog facility code.
+type SyslogFacility int
+
+// The syslog facilities defined by RFC 5424.
+const (
+	SyslogFacilityKern SyslogFacility = iota
+	SyslogFacilityUser
+	SyslogFacilityMail
+	SyslogFacilityDaemon
+	SyslogFacilityAuth
+	SyslogFacilitySyslog
+	SyslogFacilityLPR
+	SyslogFacilityNews
+	SyslogFacilityUUCP
+	SyslogFacilityCron
+	SyslogFacilityAuthPriv
+	SyslogFacilityFTP
+	SyslogFacilityNTP
+	SyslogFacilityAudit
+	SyslogFacilityAlert
+	SyslogFacilityClock
+	SyslogFacilityLocal0
+	SyslogFacilityLocal1
+	SyslogFacilityLocal2
+	SyslogFacilityLocal3
+	SyslogFacilityLocal4
+	SyslogFacilityLocal5
+	SyslogFacilityLocal6
+	SyslogFacilityLocal7
+)
+
+// The syslog severities defined by RFC 5424.
+const (
+	syslogSeverityEmerg = iota
+	syslogSeverityAlert
+	syslogSeverityCrit
+	syslogSeverityErr
+	syslogSeverityWarning
+	syslogSeverityNotice
+	syslogSeverityInfo
+	syslogSeverityDebug
+)
+
+// DefaultSyslogStructuredDataID is the SD-ID used for entry fields in
+// RFC 5424 messages. 32473 is the private enterprise number reserved
+// for documentation.
+const DefaultSyslogStructuredDataID = "fields@32473"
+
+const (
+	rfc3164TimestampFormat = "Jan _2 15:04:05"
+	rfc5424TimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// syslogSeverity maps a level to a syslog severity. User-defined
+// levels use the severity of the built-in level they were registered
+// with.
+func syslogSeverity(level Level) int {
+	switch level.severity() {
+	case PanicLevel, FatalLevel:
+		return syslogSeverityCrit
+	case ErrorLevel:
+		return syslogSeverityErr
+	case WarnLevel:
+		return syslogSeverityWarning
+	case InfoLevel, PrintLevel:
+		return syslogSeverityInfo
+	default:
+		return syslogSeverityDebug
+	}
+}
+
+// SyslogFormatter formats entries as syslog messages. Entry fields
+// become RFC 5424 structured data or, for RFC 3164, key=value pairs
+// appended to the message. Each message ends with a newline which
+// SyslogWriter strips before sending.
+type SyslogFormatter struct {
+	// SyslogFmt is the message format. The default is
+	// SyslogFormatRFC5424.
+	SyslogFmt SyslogFormat
+
+	// Facility is the syslog facility. The default is
+	// SyslogFacilityUser.
+	Facility SyslogFacility
+
+	// Hostname defaults to os.Hostname().
+	Hostname string
+
+	// AppName (the RFC 3164 tag) defaults to the program name.
+	AppName string
+
+	// ProcID defaults to the process id.
+	ProcID string
+
+	// MsgID is the RFC 5424 message type. The default is empty.
+	MsgID string
+
+	// StructuredDataID is the RFC 5424 SD-ID used for entry
+	// fields. The default is DefaultSyslogStructuredDataID.
+	StructuredDataID string
+
+	// ShowPrintMessages controls whether output from the Print*()
+	// family of logging functions is written. It is dropped by
+	// default.
+	ShowPrintMessages bool
+
+	// FieldMap allows users to customize the names of the caller
+	// keys.
+	FieldMap FieldMap
+}
+
+// NewSyslogFormatter is the SyslogFormatter constructor.
+func NewSyslogFormatter() *SyslogFormatter {
+	hostname, _ := os.Hostname()
+
+	return &SyslogFormatter{
+		SyslogFmt:        SyslogFormatRFC5424,
+		Facility:         SyslogFacilityUser,
+		Hostname:         hostname,
+		AppName:          filepath.Base(os.Args[0]),
+		ProcID:           strconv.Itoa(os.Getpid()),
+		StructuredDataID: DefaultSyslogStructuredDataID,
+	}
+}
+
+// Format renders a single log entry.
+func (f *SyslogFormatter) Format(entry *Entry) ([]byte, error) {
+	if entry.Level == PrintLevel && !f.ShowPrintMessages {
+		return []byte{}, nil
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	pri := int(f.Facility)*8 + syslogSeverity(entry.Level)
+	msg := strings.TrimSuffix(entry.Message, "\n")
+	switch f.SyslogFmt {
+	case SyslogFormatRFC3164:
+		fmt.Fprintf(b, "<%d>%s %s %s", pri, entry.Time.Format(rfc3164TimestampFormat),
+			syslogHeaderField(f.Hostname, 255), syslogHeaderField(f.AppName, 32))
+		if f.ProcID != "" {
+			fmt.Fprintf(b, "[%s]", f.ProcID)
+		}
+		b.WriteString(": ")
+		b.WriteString(msg)
+		for _, key := range entry.Fields.sortedKeys() {
+			fmt.Fprintf(b, " %s=%s", key, formatFieldValue(entry.Fields[key]))
+		}
+	default:
+		fmt.Fprintf(b, "<%d>1 %s %s %s %s %s ", pri, entry.Time.Format(rfc5424TimestampFormat),
+			syslogHeaderField(f.Hostname, 255), syslogHeaderField(f.AppName, 48),
+			syslogHeaderField(f.ProcID, 128), syslogHeaderField(f.MsgID, 32))
+		f.appendStructuredData(b, entry)
+		if msg != "" {
+			b.WriteByte(' ')
+			b.WriteString(msg)
+		}
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+// appendStructuredData writes the entry fields, and the caller if it
+// is reported, as a single SD-ELEMENT or "-" if there are none.
+func (f *SyslogFormatter) appendStructuredData(b *bytes.Buffer, entry *Entry) {
+	if len(entry.Fields) == 0 && !entry.HasCaller() {
+		b.WriteByte('-')
+		return
+	}
+
+	id := f.StructuredDataID
+	if id == "" {
+		id = DefaultSyslogStructuredDataID
+	}
+	b.WriteByte('[')
+	b.WriteString(syslogSDName(id))
+	if entry.HasCaller() {
+		f.appendSDParam(b, f.FieldMap.resolve(FieldKeyFunc), entry.Caller.Function)
+		f.appendSDParam(b, f.FieldMap.resolve(FieldKeyFile), fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line))
+	}
+	for _, key := range entry.Fields.sortedKeys() {
+		var value string
+		switch v := entry.Fields[key].(type) {
+		case string:
+			value = v
+		case error:
+			value = v.Error()
+		default:
+			value = fmt.Sprint(v)
+		}
+		f.appendSDParam(b, key, value)
+	}
+	b.WriteByte(']')
+}
+
+func (f *SyslogFormatter) appendSDParam(b *bytes.Buffer, name string, value string) {
+	b.WriteByte(' ')
+	b.WriteString(syslogSDName(name))
+	b.WriteString(`="`)
+	for _, r := range value {
+		if r == '"' || r == '\\' || r == ']' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+}
+
+// syslogHeaderField returns value restricted to the printable ASCII
+// characters, without spaces, and at most max bytes allowed in a
+// syslog header field. An empty value becomes the nil value "-".
+func syslogHeaderField(value string, max int) string {
+	if value == "" {
+		return "-"
+	}
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+
+	return value
+}
+
+// syslogSDName returns name restricted to the characters allowed in
+// an RFC 5424 SD-NAME.
+func syslogSDName(name string) string {
+	name = syslogHeaderField(name, 32)
+
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+}
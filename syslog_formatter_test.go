@@ -0,0 +1,102 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSyslogFormatter(format conlog.SyslogFormat) *conlog.SyslogFormatter {
+	formatter := conlog.NewSyslogFormatter()
+	formatter.SyslogFmt = format
+	formatter.Facility = conlog.SyslogFacilityDaemon
+	formatter.Hostname = "host"
+	formatter.AppName = "app"
+	formatter.ProcID = "42"
+
+	return formatter
+}
+
+func TestSyslogFormatter_RFC5424(t *testing.T) {
+	formatter := newTestSyslogFormatter(conlog.SyslogFormatRFC5424)
+	formatter.MsgID = "ID47"
+	timestamp := time.Date(2019, 1, 2, 15, 4, 5, 6000, time.UTC)
+
+	var tests = []struct {
+		Entry  conlog.Entry
+		CmpStr string
+	}{
+		{
+			conlog.Entry{Time: timestamp, Level: conlog.ErrorLevel, Message: "Error test\n"},
+			"<27>1 2019-01-02T15:04:05.000006Z host app 42 ID47 - Error test\n",
+		},
+		{
+			conlog.Entry{Time: timestamp, Level: conlog.InfoLevel, Message: "Info test\n",
+				Fields: conlog.Fields{"user": "bob", "quote": `a"b]`, "error": fmt.Errorf("boom")}},
+			`<30>1 2019-01-02T15:04:05.000006Z host app 42 ID47 [fields@32473 error="boom" quote="a\"b\]" user="bob"] Info test` + "\n",
+		},
+		{
+			conlog.Entry{Time: timestamp, Level: conlog.FatalLevel, Message: "Fatal test\n"},
+			"<26>1 2019-01-02T15:04:05.000006Z host app 42 ID47 - Fatal test\n",
+		},
+		{
+			conlog.Entry{Time: timestamp, Level: conlog.TraceLevel, Message: "Trace test\n"},
+			"<31>1 2019-01-02T15:04:05.000006Z host app 42 ID47 - Trace test\n",
+		},
+		{
+			conlog.Entry{Time: timestamp, Level: auditLevel, Message: "Audit test\n"},
+			"<28>1 2019-01-02T15:04:05.000006Z host app 42 ID47 - Audit test\n",
+		},
+	}
+
+	for _, test := range tests {
+		out, err := formatter.Format(&test.Entry)
+		assert.NoError(t, err)
+		t.Logf("out string = %q", string(out))
+		t.Logf("cmp string = %q", test.CmpStr)
+		assert.Equal(t, test.CmpStr, string(out))
+	}
+}
+
+func TestSyslogFormatter_RFC3164(t *testing.T) {
+	formatter := newTestSyslogFormatter(conlog.SyslogFormatRFC3164)
+	entry := conlog.Entry{
+		Time:    time.Date(2019, 1, 2, 15, 4, 5, 0, time.UTC),
+		Level:   conlog.WarnLevel,
+		Message: "Warn test\n",
+		Fields:  conlog.Fields{"port": 8080, "user": "bob smith"},
+	}
+
+	out, err := formatter.Format(&entry)
+	assert.NoError(t, err)
+	cmpStr := `<28>Jan  2 15:04:05 host app[42]: Warn test port=8080 user="bob smith"` + "\n"
+	t.Logf("out string = %q", string(out))
+	t.Logf("cmp string = %q", cmpStr)
+	assert.Equal(t, cmpStr, string(out))
+}
+
+func TestSyslogFormatter_PrintMessages(t *testing.T) {
+	formatter := newTestSyslogFormatter(conlog.SyslogFormatRFC5424)
+	formatter.Hostname = ""
+	entry := conlog.Entry{
+		Time:    time.Date(2019, 1, 2, 15, 4, 5, 0, time.UTC),
+		Level:   conlog.PrintLevel,
+		Message: "Print test",
+	}
+
+	out, err := formatter.Format(&entry)
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+
+	formatter.ShowPrintMessages = true
+	out, err = formatter.Format(&entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "<30>1 2019-01-02T15:04:05.000000Z - app 42 - - Print test\n", string(out))
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// syslogLocalPaths are the unix datagram sockets tried, in order, to
+// reach the local syslog daemon.
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// DefaultSyslogDialTimeout is the timeout used to connect to a remote
+// syslog server.
+const DefaultSyslogDialTimeout = 10 * time.Second
+
+// SyslogWriter is an io.WriteCloser that sends each write as one
+// syslog message. It is meant to be used with SyslogFormatter, e.g.,
+//
+//	w, err := conlog.NewSyslogWriter("", "")
+//	log.SetOutput(w)
+//	log.SetErrorOutput(w)
+//	log.SetFormatter(conlog.NewSyslogFormatter())
+//
+// A trailing newline is stripped from each message. Messages sent
+// over TCP are framed with octet counting (RFC 6587). If a write
+// fails the connection is re-established and the write is retried
+// once.
+type SyslogWriter struct {
+	network string
+	addr    string
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+}
+
+// NewSyslogWriter connects to a syslog server. The network is
+// "unixgram", "udp", or "tcp" (or their 4/6 variants). An empty
+// network and address connect to the local syslog daemon via
+// /dev/log.
+func NewSyslogWriter(network string, addr string) (*SyslogWriter, error) {
+	switch network {
+	case "", "unixgram", "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("Not a valid syslog network: %q", network)
+	}
+	if network == "" && addr != "" {
+		network = "unixgram"
+	}
+	w := &SyslogWriter{
+		network: network,
+		addr:    addr,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write sends p as a single syslog message. It returns os.ErrClosed
+// after Close.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte{'\n'})
+	if len(msg) == 0 {
+		return len(p), nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if err := w.send(msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog server.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
+
+func (w *SyslogWriter) isStream() bool {
+	switch w.network {
+	case "tcp", "tcp4", "tcp6":
+		return true
+	}
+
+	return false
+}
+
+func (w *SyslogWriter) send(msg []byte) error {
+	if w.isStream() {
+		_, err := fmt.Fprintf(w.conn, "%d %s", len(msg), msg)
+		return err
+	}
+	_, err := w.conn.Write(msg)
+
+	return err
+}
+
+func (w *SyslogWriter) connect() error {
+	if w.network != "" {
+		conn, err := net.DialTimeout(w.network, w.addr, DefaultSyslogDialTimeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		return nil
+	}
+
+	for _, path := range syslogLocalPaths {
+		conn, err := net.Dial("unixgram", path)
+		if err == nil {
+			w.conn = conn
+			return nil
+		}
+	}
+
+	return errors.New("Unable to connect to the local syslog daemon")
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSyslogLogger(t *testing.T, network string, addr string) (*conlog.Logger, *conlog.SyslogWriter) {
+	w, err := conlog.NewSyslogWriter(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter := newTestSyslogFormatter(conlog.SyslogFormatRFC3164)
+	log := conlog.NewLogger()
+	log.SetOutput(w)
+	log.SetErrorOutput(w)
+	log.SetFormatter(formatter)
+
+	return log, w
+}
+
+func readSyslogPacket(t *testing.T, conn net.PacketConn) string {
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+
+	return string(buf[:n])
+}
+
+func TestSyslogWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	log, w := newSyslogLogger(t, "udp", conn.LocalAddr().String())
+	defer w.Close()
+	log.Info("Info test")
+	log.Error("Error test")
+
+	msg := readSyslogPacket(t, conn)
+	t.Logf("msg = %q", msg)
+	assert.True(t, strings.HasPrefix(msg, "<30>"))
+	assert.True(t, strings.HasSuffix(msg, " host app[42]: Info test"))
+	msg = readSyslogPacket(t, conn)
+	t.Logf("msg = %q", msg)
+	assert.True(t, strings.HasPrefix(msg, "<27>"))
+	assert.True(t, strings.HasSuffix(msg, " host app[42]: Error test"))
+}
+
+func TestSyslogWriter_Unixgram(t *testing.T) {
+	dir, cleanup := newRotatingFileDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skipf("unixgram not supported: %v", err)
+	}
+	defer conn.Close()
+
+	log, w := newSyslogLogger(t, "", path)
+	defer w.Close()
+	log.Warn("Warn test")
+
+	msg := readSyslogPacket(t, conn)
+	t.Logf("msg = %q", msg)
+	assert.True(t, strings.HasPrefix(msg, "<28>"))
+	assert.True(t, strings.HasSuffix(msg, " host app[42]: Warn test"))
+}
+
+func TestSyslogWriter_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	msgs := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSuffix(length, " "))
+			buf := make([]byte, n)
+			if _, err := r.Read(buf); err != nil {
+				return
+			}
+			msgs <- string(buf)
+		}
+	}()
+
+	log, w := newSyslogLogger(t, "tcp", listener.Addr().String())
+	defer w.Close()
+	log.Info("Info test")
+	log.WithField("n", 1).Info("Info test 2")
+
+	for _, cmp := range []string{" host app[42]: Info test", " host app[42]: Info test 2 n=1"} {
+		select {
+		case msg := <-msgs:
+			t.Logf("msg = %q", msg)
+			assert.True(t, strings.HasSuffix(msg, cmp))
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for syslog message")
+		}
+	}
+}
+
+func TestSyslogWriter_BadNetwork(t *testing.T) {
+	_, err := conlog.NewSyslogWriter("ip", "127.0.0.1")
+	assert.Error(t, err)
+}
+
+func TestSyslogWriter_WriteAfterClose(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := conlog.NewSyslogWriter("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Close())
+	n, err := w.Write([]byte("Info test\n"))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, os.ErrClosed, err)
+	assert.NoError(t, w.Close())
+}
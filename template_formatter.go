@@ -0,0 +1,219 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// DefaultTemplateFormat is the template used by NewTemplateFormatter
+// when it is passed an empty string. It outputs the message followed
+// by any fields.
+const DefaultTemplateFormat = `{{.Message}}{{with .Fields}} {{fields .}}{{end}}`
+
+// TemplateEntry is the data passed to a TemplateFormatter template
+// for each log entry.
+type TemplateEntry struct {
+	// Level the entry was logged at.
+	Level Level
+
+	// Message logged with the trailing newline, if any, removed.
+	Message string
+
+	// Fields attached to the entry.
+	Fields Fields
+
+	// Timestamp is the time the entry was created.
+	Timestamp time.Time
+
+	// Caller is the calling file, line, and function, or nil if
+	// the logger does not have ReportCaller enabled.
+	Caller *runtime.Frame
+}
+
+// Time returns the entry timestamp formatted with layout, e.g.,
+// {{.Time "15:04:05"}}.
+func (e *TemplateEntry) Time(layout string) string {
+	return e.Timestamp.Format(layout)
+}
+
+// levelText is a piece of text derived from a Level. It remembers
+// the level so helpers later in a pipeline can still colorize it,
+// e.g., {{.Level | upper | pad 7 | color}}.
+type levelText struct {
+	level    Level
+	hasLevel bool
+	text     string
+}
+
+// String returns the text.
+func (t levelText) String() string {
+	return t.text
+}
+
+func toLevelText(v interface{}) levelText {
+	switch v := v.(type) {
+	case levelText:
+		return v
+	case Level:
+		return levelText{level: v, hasLevel: true, text: v.String()}
+	default:
+		return levelText{text: fmt.Sprint(v)}
+	}
+}
+
+func (t levelText) with(text string) levelText {
+	t.text = text
+	return t
+}
+
+// TemplateFormatter formats logs using a text/template. The template
+// is executed with a *TemplateEntry for each entry. In addition to
+// the standard template functions, the following helper functions
+// are available:
+//
+//	color    colorizes a level (or text derived from a level) when
+//	         output is to a terminal
+//	pad N    right-pads text to N characters (left-pads if N < 0)
+//	upper    upper-cases text, e.g., "INFO"
+//	lower    lower-cases text, e.g., "info"
+//	title    title-cases text, e.g., "Info"
+//	short    abbreviates a level to its four letter form, e.g., "INFO"
+//	elapsed  returns the number of seconds between program start and
+//	         a time, e.g., {{elapsed .Timestamp | printf "%04d"}}
+//	fields   renders fields as space-separated key=value pairs
+//
+// For example:
+//
+//	{{.Time "15:04:05"}} {{.Level | upper | pad 7 | color}} {{.Message}}
+//
+// A newline is appended to the output if the message ended with one.
+// Print*-style messages are output as-is without using the template.
+type TemplateFormatter struct {
+	// ForceColors colorizes output even when it is not going to
+	// a terminal.
+	ForceColors bool
+
+	tmpl *template.Template
+
+	// Whether the logger's out is to a terminal.
+	isTerminal bool
+
+	sync.Once
+}
+
+// NewTemplateFormatter is the TemplateFormatter constructor. It
+// parses text as the template used to format each entry. An empty
+// text uses DefaultTemplateFormat.
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	if text == "" {
+		text = DefaultTemplateFormat
+	}
+	f := &TemplateFormatter{}
+	tmpl, err := template.New("conlog").Funcs(f.funcMap()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	f.tmpl = tmpl
+
+	return f, nil
+}
+
+func (f *TemplateFormatter) init(entry *Entry) {
+	f.isTerminal = (&StdFormatter{}).checkIfTerminal(entry.Log.out)
+}
+
+func (f *TemplateFormatter) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"color": func(v interface{}) levelText {
+			t := toLevelText(v)
+			if !t.hasLevel || !(f.ForceColors || f.isTerminal) {
+				return t
+			}
+			std := &StdFormatter{}
+			return t.with(std.colorOn(t.level) + t.text + std.colorOff(t.level))
+		},
+		"pad": func(width int, v interface{}) levelText {
+			t := toLevelText(v)
+			if width < 0 {
+				return t.with(fmt.Sprintf("%*s", -width, t.text))
+			}
+			return t.with(fmt.Sprintf("%-*s", width, t.text))
+		},
+		"upper": func(v interface{}) levelText {
+			t := toLevelText(v)
+			return t.with(strings.ToUpper(t.text))
+		},
+		"lower": func(v interface{}) levelText {
+			t := toLevelText(v)
+			return t.with(strings.ToLower(t.text))
+		},
+		"title": func(v interface{}) levelText {
+			t := toLevelText(v)
+			return t.with(strings.Title(strings.ToLower(t.text)))
+		},
+		"short": func(v interface{}) levelText {
+			t := toLevelText(v)
+			if t.hasLevel {
+				return t.with(t.level.shortName())
+			}
+			text := strings.ToUpper(t.text)
+			if len(text) > 4 {
+				text = text[0:4]
+			}
+			return t.with(text)
+		},
+		"elapsed": func(t time.Time) int {
+			return int(t.Sub(baseTimestamp) / time.Second)
+		},
+		"fields": func(fields Fields) string {
+			pairs := make([]string, 0, len(fields))
+			for _, key := range fields.sortedKeys() {
+				pairs = append(pairs, key+"="+formatFieldValue(fields[key]))
+			}
+			return strings.Join(pairs, " ")
+		},
+	}
+}
+
+// Format renders a single log entry.
+func (f *TemplateFormatter) Format(entry *Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	f.Do(func() { f.init(entry) })
+
+	if entry.Level == PrintLevel {
+		b.WriteString(entry.Message)
+		return b.Bytes(), nil
+	}
+
+	msg := strings.TrimSuffix(entry.Message, "\n")
+	data := &TemplateEntry{
+		Level:     entry.Level,
+		Message:   msg,
+		Fields:    entry.Fields,
+		Timestamp: entry.Time,
+		Caller:    entry.Caller,
+	}
+	if err := f.tmpl.Execute(b, data); err != nil {
+		return nil, err
+	}
+	if len(msg) != len(entry.Message) {
+		b.WriteByte('\n')
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFormatter_Format(t *testing.T) {
+	var tests = []struct {
+		Template string
+		CmpStr   string
+	}{
+		{"", "Template test count=2\n"},
+		{"{{.Level | upper}}: {{.Message}}", "WARNING: Template test\n"},
+		{"[{{.Level | short | pad 5}}] {{.Message}}", "[WARN ] Template test\n"},
+		{"{{.Level | title | pad -8}}|{{.Message}}", " Warning|Template test\n"},
+		{"{{.Level | lower | color}} {{.Message}}", "\x1b[33mwarning\x1b[0m Template test\n"},
+		{"{{.Time \"2006\"}} {{.Message}}", time.Now().Format("2006") + " Template test\n"},
+		{"{{elapsed .Timestamp | printf \"%T\"}} {{.Message}}", "int Template test\n"},
+		{"{{.Message}} ({{index .Fields \"count\"}})", "Template test (2)\n"},
+	}
+
+	for _, test := range tests {
+		formatter, err := conlog.NewTemplateFormatter(test.Template)
+		assert.NoError(t, err)
+		formatter.ForceColors = true
+		out := &bytes.Buffer{}
+		log := conlog.NewLogger()
+		log.SetOutput(out)
+		log.SetFormatter(formatter)
+
+		log.WithField("count", 2).Warn("Template test")
+		t.Logf("template   = %q", test.Template)
+		t.Logf("out string = %q", out.String())
+		t.Logf("cmp string = %q", test.CmpStr)
+		assert.Equal(t, test.CmpStr, out.String())
+
+		// Print* output bypasses the template.
+		out.Reset()
+		log.Print("Raw print")
+		assert.Equal(t, "Raw print", out.String())
+	}
+}
+
+func TestTemplateFormatter_ParseError(t *testing.T) {
+	_, err := conlog.NewTemplateFormatter("{{.Level | nosuchfunc}}")
+	assert.Error(t, err)
+}
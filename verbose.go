@@ -0,0 +1,71 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog
+
+import (
+	"fmt"
+)
+
+// Verbose is returned by V. Its Print* methods behave like the
+// corresponding Logger methods, but output only to the loggers whose
+// verbosity was high enough when V was called. It is typically used
+// inline, e.g.,
+//
+//	log.V(1).Println("Reading", name)
+//
+// or, to avoid computing arguments that are discarded,
+//
+//	if v := log.V(3); v.Enabled() {
+//	    v.Printf("%s\n", dump(packet))
+//	}
+type Verbose struct {
+	loggers []*Logger
+
+	// ConLogger implementations without a V method, which are
+	// output to with Print.
+	others []ConLogger
+}
+
+// Enabled returns true if the Print* methods output anything.
+func (v Verbose) Enabled() bool {
+	return len(v.loggers) > 0 || len(v.others) > 0
+}
+
+// Print prints a message ala fmt.Print. No newline is added.
+func (v Verbose) Print(args ...interface{}) {
+	if v.Enabled() {
+		v.print(fmt.Sprint(args...))
+	}
+}
+
+// Printf prints a message ala fmt.Printf. No newline is added.
+func (v Verbose) Printf(format string, args ...interface{}) {
+	if v.Enabled() {
+		v.print(fmt.Sprintf(format, args...))
+	}
+}
+
+// Println prints a message ala fmt.Println.
+func (v Verbose) Println(args ...interface{}) {
+	if v.Enabled() {
+		v.print(fmt.Sprintln(args...))
+	}
+}
+
+func (v Verbose) print(msg string) {
+	for _, log := range v.loggers {
+		entry := log.newEntry()
+		entry.log(PrintLevel, msg)
+		log.releaseEntry(entry)
+	}
+	for _, logger := range v.others {
+		logger.Print(msg)
+	}
+}
+
+// verboser is implemented by *Logger and *Loggers.
+type verboser interface {
+	V(v int) Verbose
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Secure64 Software Corporation. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can
+// be found in the LICENSE file.
+
+package conlog_test
+
+import (
+	"testing"
+
+	"github.com/apatters/go-conlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerbose_Logger(t *testing.T) {
+	logger, out, _ := newSimpleLogger(conlog.InfoLevel)
+	assert.Equal(t, 0, logger.GetVerbosity())
+
+	logger.Print("0")
+	logger.V(0).Print("0")
+	logger.V(1).Print("1")
+	assert.Equal(t, "00", out.String())
+	out.Reset()
+
+	logger.SetVerbosity(2)
+	assert.Equal(t, 2, logger.GetVerbosity())
+	logger.V(1).Printf("%d", 1)
+	logger.V(2).Println(2)
+	logger.V(3).Print(3)
+	assert.True(t, logger.V(2).Enabled())
+	assert.False(t, logger.V(3).Enabled())
+	assert.Equal(t, "12\n", out.String())
+	out.Reset()
+
+	// A negative verbosity suppresses all Print*-style output.
+	logger.SetVerbosity(-1)
+	logger.Print("Print")
+	logger.Printf("Printf")
+	logger.Println("Println")
+	logger.WithField("key", "value").Printf("Entry Printf")
+	assert.Empty(t, out.String())
+
+	// SetPrintEnabled(false) suppresses all tiers.
+	logger.SetVerbosity(3)
+	logger.SetPrintEnabled(false)
+	logger.V(1).Print("1")
+	logger.WithField("key", "value").Printf("Entry Printf")
+	assert.Empty(t, out.String())
+}
+
+func TestVerbose_Loggers(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	loggers := conlog.NewLoggers(loggerList...)
+
+	loggers.SetVerbosity(1)
+	loggerList[1].SetVerbosity(2)
+	assert.Equal(t, 2, loggers.GetVerbosity())
+
+	loggers.V(1).Print("1")
+	loggers.V(2).Print("2")
+	loggers.V(3).Print("3")
+	assert.Equal(t, "1", outs[0].String())
+	assert.Equal(t, "12", outs[1].String())
+}
+
+// plainLogger is a ConLogger implementation without a V method.
+type plainLogger struct {
+	conlog.ConLogger
+}
+
+func TestVerbose_LoggersOther(t *testing.T) {
+	loggerList, outs := newSimpleLoggers(conlog.InfoLevel)
+	loggers := conlog.NewLoggers(plainLogger{loggerList[0]}, loggerList[1])
+
+	loggerList[0].SetVerbosity(2)
+	loggers.V(1).Print("1")
+	loggers.V(2).Print("2")
+	assert.Equal(t, "12", outs[0].String())
+	assert.Equal(t, "", outs[1].String())
+	assert.True(t, loggers.V(2).Enabled())
+	assert.False(t, loggers.V(3).Enabled())
+}